@@ -0,0 +1,422 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+)
+
+// topPrediction returns the highest scoring label in preds
+// Ties go to the alphabetically first label so results are stable
+func topPrediction(preds map[string]float64) (string, float64) {
+	best, bestScore := "", 0.0
+	for label, score := range preds {
+		if best == "" || score > bestScore || (score == bestScore && label < best) {
+			best, bestScore = label, score
+		}
+	}
+	return best, bestScore
+}
+
+// computeConfusionMatrix counts human labels against the model's top prediction,
+// keyed by true label then predicted label
+// Items without a label or without predictions are left out
+func computeConfusionMatrix() map[string]map[string]int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return confusionMatrixFor(dataset)
+}
+
+func confusionMatrixFor(items []DataItem) map[string]map[string]int {
+	matrix := make(map[string]map[string]int)
+	for _, item := range items {
+		if item.Label == "" || len(item.ModelPreds) == 0 {
+			continue
+		}
+		predicted, _ := topPrediction(item.ModelPreds)
+		if matrix[item.Label] == nil {
+			matrix[item.Label] = make(map[string]int)
+		}
+		matrix[item.Label][predicted]++
+	}
+	return matrix
+}
+
+// scoreConfusionMatrix returns accuracy and macro-averaged F1 for a confusion matrix
+func scoreConfusionMatrix(matrix map[string]map[string]int) (accuracy, f1 float64) {
+	labels := make(map[string]bool)
+	total, correct := 0, 0
+	truePositives := make(map[string]int)
+	actual := make(map[string]int)
+	predicted := make(map[string]int)
+	for trueLabel, row := range matrix {
+		labels[trueLabel] = true
+		for predLabel, count := range row {
+			labels[predLabel] = true
+			total += count
+			actual[trueLabel] += count
+			predicted[predLabel] += count
+			if trueLabel == predLabel {
+				correct += count
+				truePositives[trueLabel] += count
+			}
+		}
+	}
+	if total == 0 {
+		return 0, 0
+	}
+
+	f1Sum := 0.0
+	for label := range labels {
+		tp := float64(truePositives[label])
+		if tp == 0 {
+			continue
+		}
+		precision := tp / float64(predicted[label])
+		recall := tp / float64(actual[label])
+		f1Sum += 2 * precision * recall / (precision + recall)
+	}
+	return float64(correct) / float64(total), f1Sum / float64(len(labels))
+}
+
+// cohensKappa measures agreement between two annotators over the item IDs
+// both have labeled, corrected for the agreement expected by chance
+func cohensKappa(labelsA, labelsB map[int]string) float64 {
+	total := 0
+	agreed := 0
+	countsA := make(map[string]int)
+	countsB := make(map[string]int)
+	for id, labelA := range labelsA {
+		labelB, ok := labelsB[id]
+		if !ok {
+			continue
+		}
+		total++
+		countsA[labelA]++
+		countsB[labelB]++
+		if labelA == labelB {
+			agreed++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	observed := float64(agreed) / float64(total)
+	expected := 0.0
+	for label, count := range countsA {
+		expected += float64(count) / float64(total) * float64(countsB[label]) / float64(total)
+	}
+	// Both annotators used a single, shared category
+	if expected == 1 {
+		return 1
+	}
+	return (observed - expected) / (1 - expected)
+}
+
+// labelKappa compares the dataset's current labels with a second set of
+// annotations keyed by item ID
+func labelKappa(other map[int]string) float64 {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	current := make(map[int]string)
+	for _, item := range dataset {
+		if item.Label != "" {
+			current[item.ID] = item.Label
+		}
+	}
+	return cohensKappa(current, other)
+}
+
+// categoryMetrics computes metrics separately for each category in the dataset
+func categoryMetrics() map[string]MetricsData {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	partitions := make(map[string][]DataItem)
+	for _, item := range dataset {
+		partitions[item.Category] = append(partitions[item.Category], item)
+	}
+
+	results := make(map[string]MetricsData, len(partitions))
+	for category, items := range partitions {
+		results[category] = metricsFor(items)
+	}
+	return results
+}
+
+// QualityScoreMethod selects how calculateDistributionScore rates label balance
+type QualityScoreMethod int
+
+const (
+	QualityScoreDeviation QualityScoreMethod = iota // mean absolute deviation from an even split
+	QualityScoreEntropy                             // Shannon entropy normalized to [0,1]
+)
+
+// qualityScoreMethod is the method used for MetricsData.DistributionScore
+var qualityScoreMethod = QualityScoreDeviation
+
+// calculateDistributionScore rates how evenly items are spread across labels,
+// from 0 (all in one label) to 1 (perfectly even)
+// Fewer than two labels count as perfectly even, and no labels score 0
+func calculateDistributionScore(distribution map[string]int) float64 {
+	total := 0
+	for _, count := range distribution {
+		total += count
+	}
+	if total == 0 {
+		return 0
+	}
+	classes := float64(len(distribution))
+	if classes < 2 {
+		return 1
+	}
+
+	if qualityScoreMethod == QualityScoreEntropy {
+		entropy := 0.0
+		for _, count := range distribution {
+			if count > 0 {
+				p := float64(count) / float64(total)
+				entropy -= p * math.Log(p)
+			}
+		}
+		return entropy / math.Log(classes)
+	}
+
+	mean := float64(total) / classes
+	deviation := 0.0
+	for _, count := range distribution {
+		deviation += math.Abs(float64(count) - mean)
+	}
+	// Largest possible deviation, reached when every item has the same label
+	maxDeviation := 2 * float64(total) * (classes - 1) / classes
+	return math.Max(0, 1-deviation/maxDeviation)
+}
+
+// QualityWeights set how much each part contributes to qualityScore
+// Only their ratios matter, as they are normalized to sum to 1
+type QualityWeights struct {
+	Verification float64 // fraction of items verified
+	Balance      float64 // the distribution score
+	Agreement    float64 // inter-annotator kappa, when one is supplied
+	// AutoCredit is how much an auto-accepted item counts towards
+	// verification, from 0 (not at all) to 1 (as much as a human check)
+	AutoCredit float64
+}
+
+// qualityWeights is used by qualityScore
+// The default weighs verification and balance equally
+var qualityWeights = QualityWeights{Verification: 1, Balance: 1}
+
+// qualityScore combines verification, label balance and, when agreement is
+// not nil, inter-annotator agreement into a score from 0 to 1
+// Without an agreement its weight is dropped and the others renormalized;
+// negative kappa counts as no agreement
+func qualityScore(metrics MetricsData, agreement *float64) float64 {
+	weights := qualityWeights
+	if agreement == nil {
+		weights.Agreement = 0
+	}
+	total := weights.Verification + weights.Balance + weights.Agreement
+	if total <= 0 {
+		return 0
+	}
+
+	verified := (metrics.HumanVerifiedPct + weights.AutoCredit*metrics.AutoAcceptedPct) / 100
+	score := weights.Verification*verified + weights.Balance*metrics.DistributionScore
+	if agreement != nil {
+		score += weights.Agreement * math.Max(0, math.Min(1, *agreement))
+	}
+	return score / total
+}
+
+// disagreementItems returns the indices of labeled items whose top prediction
+// differs from the human label with a score above threshold
+func disagreementItems(threshold float64) []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	var results []int
+	for i, item := range dataset {
+		if item.Label == "" || len(item.ModelPreds) == 0 {
+			continue
+		}
+		predicted, score := topPrediction(item.ModelPreds)
+		if predicted != item.Label && score > threshold {
+			results = append(results, i)
+		}
+	}
+	return results
+}
+
+// predictionMargin is the gap between the top two scores in preds, or 0
+// when there are fewer than two
+func predictionMargin(preds map[string]float64) float64 {
+	if len(preds) < 2 {
+		return 0
+	}
+	first, second := math.Inf(-1), math.Inf(-1)
+	for _, score := range preds {
+		if score > first {
+			first, second = score, first
+		} else if score > second {
+			second = score
+		}
+	}
+	return first - second
+}
+
+// predictionMargins returns every item's prediction margin keyed by ID
+func predictionMargins() map[int]float64 {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	margins := make(map[int]float64, len(dataset))
+	for _, item := range dataset {
+		margins[item.ID] = predictionMargin(item.ModelPreds)
+	}
+	return margins
+}
+
+// exportMargins writes an id,margin CSV row per item in dataset order,
+// leaving out items without predictions
+func exportMargins(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"id", "margin"}); err != nil {
+		return err
+	}
+	for _, item := range dataset {
+		if len(item.ModelPreds) == 0 {
+			continue
+		}
+		record := []string{
+			strconv.Itoa(item.ID),
+			strconv.FormatFloat(predictionMargin(item.ModelPreds), 'f', -1, 64),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// calibrationMinSamples is the fewest items a calibration bin needs before
+// its accuracy is trusted
+var calibrationMinSamples = 5
+
+// CalibrationBin is one confidence range of a label's calibration curve
+type CalibrationBin struct {
+	Confidence float64 // mean top prediction score, or the range's midpoint when empty
+	Accuracy   float64 // share of the items whose verified label matches the prediction
+	Count      int
+	LowSample  bool // fewer than calibrationMinSamples items
+}
+
+// calibrationReport splits verified items by their top predicted label
+// and sorts each label's items into bins equal-width confidence ranges
+// from 0 to 1, measuring how often the prediction was right in each
+// A well calibrated model has Accuracy close to Confidence in every bin
+func calibrationReport(bins int) map[string][]CalibrationBin {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	report := make(map[string][]CalibrationBin)
+	if bins <= 0 {
+		return report
+	}
+	totals := make(map[string][]float64)
+	correct := make(map[string][]int)
+	for _, item := range dataset {
+		if !item.UserVerified || item.Label == "" || len(item.ModelPreds) == 0 {
+			continue
+		}
+		predicted, score := topPrediction(item.ModelPreds)
+		if report[predicted] == nil {
+			report[predicted] = make([]CalibrationBin, bins)
+			totals[predicted] = make([]float64, bins)
+			correct[predicted] = make([]int, bins)
+		}
+		bin := int(math.Max(0, score) * float64(bins))
+		if bin >= bins {
+			bin = bins - 1
+		}
+		report[predicted][bin].Count++
+		totals[predicted][bin] += score
+		if item.Label == predicted {
+			correct[predicted][bin]++
+		}
+	}
+
+	for label, curve := range report {
+		for i := range curve {
+			bin := &curve[i]
+			bin.LowSample = bin.Count < calibrationMinSamples
+			if bin.Count == 0 {
+				bin.Confidence = (float64(i) + 0.5) / float64(bins)
+				continue
+			}
+			bin.Confidence = totals[label][i] / float64(bin.Count)
+			bin.Accuracy = float64(correct[label][i]) / float64(bin.Count)
+		}
+	}
+	return report
+}
+
+// TextLengthStats summarizes item text lengths in characters
+// Median doubles as the 50th percentile
+type TextLengthStats struct {
+	Min    int
+	Max    int
+	Mean   float64
+	Median float64
+	P90    float64
+	P99    float64
+}
+
+// textLengthStats measures the text length of every item
+// Percentiles interpolate linearly between the nearest lengths
+func textLengthStats() TextLengthStats {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if len(dataset) == 0 {
+		return TextLengthStats{}
+	}
+	lengths := make([]int, len(dataset))
+	total := 0
+	for i, item := range dataset {
+		lengths[i] = utf8.RuneCountInString(item.Text)
+		total += lengths[i]
+	}
+	sort.Ints(lengths)
+
+	return TextLengthStats{
+		Min:    lengths[0],
+		Max:    lengths[len(lengths)-1],
+		Mean:   float64(total) / float64(len(lengths)),
+		Median: percentile(lengths, 50),
+		P90:    percentile(lengths, 90),
+		P99:    percentile(lengths, 99),
+	}
+}
+
+// percentile reads the pth percentile from sorted, non-empty values
+func percentile(sorted []int, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	if lower+1 >= len(sorted) {
+		return float64(sorted[lower])
+	}
+	fraction := rank - float64(lower)
+	return float64(sorted[lower]) + fraction*float64(sorted[lower+1]-sorted[lower])
+}
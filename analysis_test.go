@@ -0,0 +1,254 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// approxEqual reports whether a and b agree to within rounding
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestTopPrediction(t *testing.T) {
+	tests := []struct {
+		preds     map[string]float64
+		wantLabel string
+		wantScore float64
+	}{
+		{nil, "", 0},
+		{map[string]float64{"a": 0.2, "b": 0.8}, "b", 0.8},
+		{map[string]float64{"z": 0.5, "m": 0.5, "q": 0.1}, "m", 0.5},
+		{map[string]float64{"neg": -1}, "neg", -1},
+	}
+	for _, tt := range tests {
+		label, score := topPrediction(tt.preds)
+		if label != tt.wantLabel || score != tt.wantScore {
+			t.Errorf("topPrediction(%v) = %q, %v, want %q, %v", tt.preds, label, score, tt.wantLabel, tt.wantScore)
+		}
+	}
+}
+
+func TestConfusionMatrix(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Label: "a", ModelPreds: map[string]float64{"a": 0.9, "b": 0.1}},
+		DataItem{ID: 2, Label: "a", ModelPreds: map[string]float64{"b": 0.7}},
+		DataItem{ID: 3, Label: "b", ModelPreds: map[string]float64{"b": 0.6}},
+		DataItem{ID: 4, Label: "b"},
+		DataItem{ID: 5, ModelPreds: map[string]float64{"a": 1}},
+	)
+	matrix := computeConfusionMatrix()
+	want := map[string]map[string]int{"a": {"a": 1, "b": 1}, "b": {"b": 1}}
+	if !reflect.DeepEqual(matrix, want) {
+		t.Fatalf("matrix = %v, want %v", matrix, want)
+	}
+
+	accuracy, f1 := scoreConfusionMatrix(matrix)
+	// a: precision 1, recall 1/2; b: precision 1/2, recall 1; both F1 2/3
+	if !approxEqual(accuracy, 2.0/3) || !approxEqual(f1, 2.0/3) {
+		t.Errorf("accuracy, f1 = %v, %v, want 2/3, 2/3", accuracy, f1)
+	}
+	if accuracy, f1 := scoreConfusionMatrix(nil); accuracy != 0 || f1 != 0 {
+		t.Errorf("empty matrix scored %v, %v", accuracy, f1)
+	}
+}
+
+func TestCohensKappa(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b map[int]string
+		want float64
+	}{
+		{"perfect", map[int]string{1: "x", 2: "y"}, map[int]string{1: "x", 2: "y"}, 1},
+		{"single shared category", map[int]string{1: "x", 2: "x"}, map[int]string{1: "x", 2: "x"}, 1},
+		{"opposite", map[int]string{1: "x", 2: "y"}, map[int]string{1: "y", 2: "x"}, -1},
+		{"only shared IDs count", map[int]string{1: "x", 2: "y", 3: "x"}, map[int]string{1: "x", 2: "y", 4: "y"}, 1},
+		{"no overlap", map[int]string{1: "x"}, map[int]string{2: "x"}, 0},
+		// observed 3/4, expected 1/2
+		{"partial", map[int]string{1: "x", 2: "x", 3: "y", 4: "y"}, map[int]string{1: "x", 2: "y", 3: "y", 4: "y"}, 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cohensKappa(tt.a, tt.b); !approxEqual(got, tt.want) {
+				t.Errorf("cohensKappa = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	useDataset(t, DataItem{ID: 1, Label: "x"}, DataItem{ID: 2, Label: "y"}, DataItem{ID: 3})
+	if got := labelKappa(map[int]string{1: "x", 2: "y", 3: "x"}); !approxEqual(got, 1) {
+		t.Errorf("labelKappa = %v, want 1", got)
+	}
+}
+
+func TestCategoryMetrics(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Category: "news", Label: "a", UserVerified: true},
+		DataItem{ID: 2, Category: "news", Label: "b"},
+		DataItem{ID: 3, Label: "a"},
+	)
+	metrics := categoryMetrics()
+	if len(metrics) != 2 {
+		t.Fatalf("got %d categories, want 2", len(metrics))
+	}
+	if m := metrics["news"]; m.DatasetSize != 2 || m.VerifiedPct != 50 {
+		t.Errorf("news = %+v", m)
+	}
+	if m := metrics[""]; m.DatasetSize != 1 || m.LabelDistribution["a"] != 1 {
+		t.Errorf("uncategorized = %+v", m)
+	}
+}
+
+func TestCalculateDistributionScore(t *testing.T) {
+	tests := []struct {
+		name         string
+		method       QualityScoreMethod
+		distribution map[string]int
+		want         float64
+	}{
+		{"empty", QualityScoreDeviation, nil, 0},
+		{"one label", QualityScoreDeviation, map[string]int{"a": 5}, 1},
+		{"even", QualityScoreDeviation, map[string]int{"a": 5, "b": 5}, 1},
+		{"all in one", QualityScoreDeviation, map[string]int{"a": 10, "b": 0}, 0},
+		{"skewed", QualityScoreDeviation, map[string]int{"a": 3, "b": 1}, 0.5},
+		{"entropy even", QualityScoreEntropy, map[string]int{"a": 2, "b": 2, "c": 2}, 1},
+		{"entropy all in one", QualityScoreEntropy, map[string]int{"a": 4, "b": 0}, 0},
+		{"entropy skewed", QualityScoreEntropy, map[string]int{"a": 3, "b": 1},
+			-(0.75*math.Log(0.75) + 0.25*math.Log(0.25)) / math.Log(2)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qualityScoreMethod = tt.method
+			defer func() { qualityScoreMethod = QualityScoreDeviation }()
+			if got := calculateDistributionScore(tt.distribution); !approxEqual(got, tt.want) {
+				t.Errorf("score = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQualityScore(t *testing.T) {
+	agreement := func(kappa float64) *float64 { return &kappa }
+	metrics := MetricsData{HumanVerifiedPct: 50, AutoAcceptedPct: 40, DistributionScore: 1}
+	tests := []struct {
+		name      string
+		weights   QualityWeights
+		agreement *float64
+		want      float64
+	}{
+		{"default", QualityWeights{Verification: 1, Balance: 1}, nil, 0.75},
+		{"agreement dropped when nil", QualityWeights{Verification: 1, Balance: 1, Agreement: 2}, nil, 0.75},
+		{"agreement", QualityWeights{Verification: 1, Balance: 1, Agreement: 2}, agreement(0.5), 0.625},
+		{"negative kappa", QualityWeights{Verification: 1, Balance: 1, Agreement: 2}, agreement(-0.5), 0.375},
+		{"auto credit", QualityWeights{Verification: 1, AutoCredit: 0.5}, nil, 0.7},
+		{"zero weights", QualityWeights{}, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			qualityWeights = tt.weights
+			defer func() { qualityWeights = QualityWeights{Verification: 1, Balance: 1} }()
+			if got := qualityScore(metrics, tt.agreement); !approxEqual(got, tt.want) {
+				t.Errorf("qualityScore = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDisagreementItems(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Label: "a", ModelPreds: map[string]float64{"b": 0.9}},
+		DataItem{ID: 2, Label: "a", ModelPreds: map[string]float64{"b": 0.5}},
+		DataItem{ID: 3, Label: "a", ModelPreds: map[string]float64{"a": 0.9}},
+		DataItem{ID: 4, ModelPreds: map[string]float64{"b": 0.9}},
+	)
+	if got := disagreementItems(0.6); !reflect.DeepEqual(got, []int{0}) {
+		t.Errorf("disagreementItems(0.6) = %v, want [0]", got)
+	}
+	if got := disagreementItems(0.4); !reflect.DeepEqual(got, []int{0, 1}) {
+		t.Errorf("disagreementItems(0.4) = %v, want [0 1]", got)
+	}
+}
+
+func TestPredictionMargins(t *testing.T) {
+	tests := []struct {
+		preds map[string]float64
+		want  float64
+	}{
+		{nil, 0},
+		{map[string]float64{"a": 0.9}, 0},
+		{map[string]float64{"a": 0.7, "b": 0.2, "c": 0.1}, 0.5},
+		{map[string]float64{"a": 0.4, "b": 0.4}, 0},
+	}
+	for _, tt := range tests {
+		if got := predictionMargin(tt.preds); !approxEqual(got, tt.want) {
+			t.Errorf("predictionMargin(%v) = %v, want %v", tt.preds, got, tt.want)
+		}
+	}
+
+	useDataset(t,
+		DataItem{ID: 3, ModelPreds: map[string]float64{"a": 0.75, "b": 0.25}},
+		DataItem{ID: 5},
+		DataItem{ID: 8, ModelPreds: map[string]float64{"a": 1}},
+	)
+	if got, want := predictionMargins(), map[int]float64{3: 0.5, 5: 0, 8: 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("predictionMargins = %v, want %v", got, want)
+	}
+	var buf bytes.Buffer
+	if err := exportMargins(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := buf.String(), "id,margin\n3,0.5\n8,0\n"; got != want {
+		t.Errorf("exportMargins = %q, want %q", got, want)
+	}
+}
+
+func TestCalibrationReport(t *testing.T) {
+	calibrationMinSamples = 2
+	defer func() { calibrationMinSamples = 5 }()
+	useDataset(t,
+		DataItem{ID: 1, Label: "a", UserVerified: true, ModelPreds: map[string]float64{"a": 0.9}},
+		DataItem{ID: 2, Label: "b", UserVerified: true, ModelPreds: map[string]float64{"a": 0.7}},
+		DataItem{ID: 3, Label: "a", UserVerified: true, ModelPreds: map[string]float64{"a": 1}},
+		DataItem{ID: 4, Label: "a", UserVerified: true, ModelPreds: map[string]float64{"a": 0.2}},
+		DataItem{ID: 5, Label: "a", ModelPreds: map[string]float64{"a": 0.2}},
+	)
+	report := calibrationReport(2)
+	if len(report) != 1 {
+		t.Fatalf("report = %v, want only label a", report)
+	}
+	want := []CalibrationBin{
+		{Confidence: 0.2, Accuracy: 1, Count: 1, LowSample: true},
+		{Confidence: 0.8666666666666667, Accuracy: 2.0 / 3, Count: 3},
+	}
+	for i, bin := range report["a"] {
+		if !approxEqual(bin.Confidence, want[i].Confidence) || !approxEqual(bin.Accuracy, want[i].Accuracy) ||
+			bin.Count != want[i].Count || bin.LowSample != want[i].LowSample {
+			t.Errorf("bin %d = %+v, want %+v", i, bin, want[i])
+		}
+	}
+	if report := calibrationReport(0); len(report) != 0 {
+		t.Errorf("zero bins gave %v", report)
+	}
+}
+
+func TestTextLengthStats(t *testing.T) {
+	useDataset(t)
+	if got := textLengthStats(); got != (TextLengthStats{}) {
+		t.Errorf("empty dataset stats = %+v", got)
+	}
+
+	useDataset(t,
+		DataItem{ID: 1, Text: "héllo"},
+		DataItem{ID: 2, Text: "a"},
+		DataItem{ID: 3, Text: "abc"},
+		DataItem{ID: 4, Text: "abcdefghij"},
+	)
+	got := textLengthStats()
+	want := TextLengthStats{Min: 1, Max: 10, Mean: 4.75, Median: 4, P90: 8.5, P99: 9.85}
+	if got.Min != want.Min || got.Max != want.Max || !approxEqual(got.Mean, want.Mean) ||
+		!approxEqual(got.Median, want.Median) || !approxEqual(got.P90, want.P90) || !approxEqual(got.P99, want.P99) {
+		t.Errorf("textLengthStats = %+v, want %+v", got, want)
+	}
+}
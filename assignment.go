@@ -0,0 +1,67 @@
+package main
+
+// assignItems assigns the listed items to user for review
+func assignItems(indices []int, user string) error {
+	return batchUpdate(indices, map[string]interface{}{"assigned_to": user})
+}
+
+// itemsForUser returns the indices of items assigned to user, in dataset order
+func itemsForUser(user string) []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	var results []int
+	for i, item := range dataset {
+		if item.AssignedTo == user {
+			results = append(results, i)
+		}
+	}
+	return results
+}
+
+// ReviewerStat summarizes the items one reviewer verified
+type ReviewerStat struct {
+	Verified          int
+	AvgConfidence     float64
+	LabelDistribution map[string]int
+}
+
+// reviewerStats rolls up verified items by the user the audit log credits
+// with verifying them, the one who last set user_verified to true
+// Unverified items and items verified with no current user are left out
+func reviewerStats() map[string]ReviewerStat {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	verifiedBy := make(map[int]string)
+	for _, record := range auditLog {
+		if record.Field == "user_verified" && record.NewValue == true {
+			verifiedBy[record.ItemID] = record.User
+		}
+	}
+
+	stats := make(map[string]ReviewerStat)
+	confidence := make(map[string]float64)
+	for _, item := range dataset {
+		user := verifiedBy[item.ID]
+		if !item.UserVerified || user == "" {
+			continue
+		}
+		stat, ok := stats[user]
+		if !ok {
+			stat.LabelDistribution = make(map[string]int)
+		}
+		stat.Verified++
+		if item.Label != "" {
+			stat.LabelDistribution[item.Label]++
+		}
+		stats[user] = stat
+		confidence[user] += item.Confidence
+	}
+
+	for user, stat := range stats {
+		stat.AvgConfidence = confidence[user] / float64(stat.Verified)
+		stats[user] = stat
+	}
+	return stats
+}
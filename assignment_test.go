@@ -0,0 +1,52 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAssignItems(t *testing.T) {
+	useDataset(t, threeItems()...)
+	if err := assignItems([]int{0, 2}, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := assignItems([]int{1}, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if got := itemsForUser("alice"); !reflect.DeepEqual(got, []int{0, 2}) {
+		t.Errorf("alice has %v, want [0 2]", got)
+	}
+	if got := itemsForUser("carol"); got != nil {
+		t.Errorf("carol has %v", got)
+	}
+	if err := assignItems([]int{5}, "alice"); err == nil {
+		t.Error("out of range index accepted")
+	}
+}
+
+func TestReviewerStats(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Label: "a", AssignedTo: "bob", Confidence: 0.5},
+		DataItem{ID: 2, Label: "b", Confidence: 1},
+		DataItem{ID: 3, Label: "a", AssignedTo: "alice"},
+		DataItem{ID: 4, AssignedTo: "alice"},
+		DataItem{ID: 5, Label: "a", UserVerified: true},
+	)
+	// alice verifies items 1 and 2 whoever they are assigned to, bob
+	// verifies 4 and alice's later unverify of 3 leaves it out
+	currentUser = "alice"
+	setLabel(0, "a")
+	setLabel(1, "b")
+	setLabel(2, "a")
+	updateItem(2, map[string]interface{}{"user_verified": false})
+	currentUser = "bob"
+	updateItem(3, map[string]interface{}{"user_verified": true})
+
+	want := map[string]ReviewerStat{
+		"alice": {Verified: 2, AvgConfidence: 0.75, LabelDistribution: map[string]int{"a": 1, "b": 1}},
+		"bob":   {Verified: 1, LabelDistribution: map[string]int{}},
+	}
+	if got := reviewerStats(); !reflect.DeepEqual(got, want) {
+		t.Errorf("reviewerStats = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// autosavePath is where autosave writes the session between backups
+var autosavePath = "session.autosave"
+
+// enableAutosave makes dataset changes schedule an autosave
+var enableAutosave bool
+
+// autosaveEdits is how many edits trigger an autosave straight away
+var autosaveEdits = 20
+
+// autosaveInterval is the longest an edit waits before being autosaved
+var autosaveInterval = 30 * time.Second
+
+// autosaveError, when set, is called with any error from an autosave
+var autosaveError func(error)
+
+var (
+	autosaveMu      sync.Mutex
+	autosavePending int
+	autosaveTimer   *time.Timer
+	autosaveWriteMu sync.Mutex
+)
+
+// noteAutosaveEdit counts one edit towards the next autosave
+// It runs from datasetChanged with datasetMu held, so saving happens on
+// another goroutine; edits arriving before that save runs share it
+func noteAutosaveEdit() {
+	autosaveMu.Lock()
+	defer autosaveMu.Unlock()
+
+	autosavePending++
+	if autosaveEdits > 0 && autosavePending >= autosaveEdits {
+		if autosaveTimer != nil {
+			autosaveTimer.Stop()
+			autosaveTimer = nil
+		}
+		go runAutosave()
+		return
+	}
+	if autosaveTimer == nil && autosaveInterval > 0 {
+		autosaveTimer = time.AfterFunc(autosaveInterval, runAutosave)
+	}
+}
+
+// runAutosave writes the session to autosavePath if any edits are pending
+func runAutosave() {
+	autosaveMu.Lock()
+	pending := autosavePending
+	autosavePending = 0
+	if autosaveTimer != nil {
+		autosaveTimer.Stop()
+		autosaveTimer = nil
+	}
+	autosaveMu.Unlock()
+	if pending == 0 {
+		return
+	}
+
+	autosaveWriteMu.Lock()
+	defer autosaveWriteMu.Unlock()
+	if err := saveSession(autosavePath); err != nil && autosaveError != nil {
+		autosaveError(err)
+	}
+}
+
+// flushAutosave saves any pending edits now, for use on shutdown
+func flushAutosave() {
+	runAutosave()
+}
+
+// autosaveRecoverable reports whether autosavePath holds a session newer
+// than the latest backup, and so may contain edits the backups lack
+func autosaveRecoverable() bool {
+	info, err := os.Stat(autosavePath)
+	if err != nil {
+		return false
+	}
+	backups, err := listBackups()
+	if err != nil || len(backups) == 0 {
+		return true
+	}
+	latest := backups[len(backups)-1]
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(latest), ".gz"), ".json")
+	stamp, err := time.ParseInLocation(backupTimeFormat, strings.TrimPrefix(name, "backup_"), time.Local)
+	if err != nil {
+		return true
+	}
+	return info.ModTime().After(stamp)
+}
+
+// recoverAutosave replaces the current session with the autosaved one
+func recoverAutosave() error {
+	return loadSession(autosavePath)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useAutosave turns autosave on, writing to a fresh file, for the test
+func useAutosave(t *testing.T, edits int, interval time.Duration) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session.autosave")
+	savedPath, savedEdits, savedInterval := autosavePath, autosaveEdits, autosaveInterval
+	autosavePath, autosaveEdits, autosaveInterval = path, edits, interval
+	enableAutosave = true
+	t.Cleanup(func() {
+		enableAutosave = false
+		autosaveMu.Lock()
+		if autosaveTimer != nil {
+			autosaveTimer.Stop()
+			autosaveTimer = nil
+		}
+		autosavePending = 0
+		autosaveMu.Unlock()
+		// Waits for a save already running
+		autosaveWriteMu.Lock()
+		autosaveWriteMu.Unlock()
+		autosavePath, autosaveEdits, autosaveInterval = savedPath, savedEdits, savedInterval
+	})
+	return path
+}
+
+// waitForFile polls until path exists or the deadline passes
+func waitForFile(path string, within time.Duration) bool {
+	deadline := time.Now().Add(within)
+	for time.Now().Before(deadline) {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestAutosave(t *testing.T) {
+	tests := []struct {
+		name     string
+		edits    int
+		interval time.Duration
+		changes  int
+	}{
+		{"after enough edits", 2, time.Hour, 2},
+		{"after the interval", 100, 20 * time.Millisecond, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			path := useAutosave(t, tt.edits, tt.interval)
+			for i := 0; i < tt.changes; i++ {
+				setLabel(i, "saved")
+			}
+			if !waitForFile(path, 5*time.Second) {
+				t.Fatal("no autosave written")
+			}
+			// Let the write finish before reading it back
+			autosaveWriteMu.Lock()
+			autosaveWriteMu.Unlock()
+
+			useDataset(t)
+			if err := recoverAutosave(); err != nil {
+				t.Fatal(err)
+			}
+			if got := labelsOf()[0]; got != "saved" {
+				t.Errorf("recovered label = %q, want saved", got)
+			}
+		})
+	}
+}
+
+func TestAutosaveBelowThreshold(t *testing.T) {
+	useDataset(t, threeItems()...)
+	path := useAutosave(t, 5, time.Hour)
+	setLabel(0, "x")
+	if waitForFile(path, 100*time.Millisecond) {
+		t.Fatal("autosaved before the edit threshold or interval")
+	}
+	flushAutosave()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("flush did not save: %v", err)
+	}
+	// Nothing pending, so nothing is rewritten
+	os.Remove(path)
+	flushAutosave()
+	if _, err := os.Stat(path); err == nil {
+		t.Error("flush without edits saved")
+	}
+}
+
+func TestAutosaveRecoverable(t *testing.T) {
+	useDataset(t, threeItems()...)
+	dir := useBackupDir(t)
+	path := useAutosave(t, 1, time.Hour)
+	enableAutosave = false
+
+	if autosaveRecoverable() {
+		t.Error("missing autosave reported recoverable")
+	}
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !autosaveRecoverable() {
+		t.Error("autosave without backups not recoverable")
+	}
+
+	stamp := func(at time.Time) string { return "backup_" + at.Format(backupTimeFormat) + ".json" }
+	writeBackupFiles(t, dir, stamp(time.Now().Add(-time.Hour)))
+	if !autosaveRecoverable() {
+		t.Error("autosave newer than the backup not recoverable")
+	}
+	writeBackupFiles(t, dir, stamp(time.Now().Add(time.Hour)))
+	if autosaveRecoverable() {
+		t.Error("autosave older than the latest backup reported recoverable")
+	}
+	if names, _ := listBackups(); len(names) != 2 {
+		t.Errorf("backups = %v", names)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupPath is the directory backups are written to, relative to the
+// working directory unless setBackupPath has made it absolute
+var backupPath = "backups"
+
+// setBackupPath makes path, as an absolute path, the backup directory
+// after creating it if needed and checking that files can be written there
+// The previous directory is kept if the new one can't be used
+func setBackupPath(path string) error {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return fmt.Errorf("backup path %q: %w", path, err)
+	}
+	if err := os.MkdirAll(abs, 0755); err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("backup path %s: permission denied creating the directory", abs)
+		}
+		return fmt.Errorf("backup path %s: %w", abs, err)
+	}
+	probe, err := os.CreateTemp(abs, ".write-check*")
+	if err != nil {
+		if errors.Is(err, fs.ErrPermission) {
+			return fmt.Errorf("backup path %s: permission denied writing to the directory", abs)
+		}
+		return fmt.Errorf("backup path %s is not writable: %w", abs, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	backupPath = abs
+	return nil
+}
+
+// maxBackups is how many backups createBackup keeps; zero keeps all of them
+var maxBackups int
+
+// compressBackups makes createBackup write gzip-compressed .json.gz files
+var compressBackups bool
+
+// backupTimeFormat is the timestamp embedded in backup file names
+const backupTimeFormat = "20060102_150405.000"
+
+// createBackup writes the dataset to a timestamped file under backupPath
+// and returns the file's path
+func createBackup() (string, error) {
+	if err := os.MkdirAll(backupPath, 0755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(backupPath, "backup_"+time.Now().Format(backupTimeFormat)+".json")
+	if compressBackups {
+		path += ".gz"
+	}
+
+	err := writeFileAtomic(path, func(writer io.Writer) error {
+		if !compressBackups {
+			return exportJSON(writer)
+		}
+		compressor := gzip.NewWriter(writer)
+		if err := exportJSON(compressor); err != nil {
+			return err
+		}
+		return compressor.Close()
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, pruneBackups()
+}
+
+// autoBackupError, when set, is called with any error from a periodic backup
+var autoBackupError func(error)
+
+var (
+	autoBackupMu   sync.Mutex
+	autoBackupStop chan struct{}
+	autoBackupDone chan struct{}
+)
+
+// startAutoBackup calls createBackup every interval until stopAutoBackup,
+// replacing any periodic backup already running
+func startAutoBackup(interval time.Duration) {
+	stopAutoBackup()
+
+	autoBackupMu.Lock()
+	defer autoBackupMu.Unlock()
+	stop, done := make(chan struct{}), make(chan struct{})
+	autoBackupStop, autoBackupDone = stop, done
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if _, err := createBackup(); err != nil && autoBackupError != nil {
+					autoBackupError(err)
+				}
+			}
+		}
+	}()
+}
+
+// stopAutoBackup halts periodic backups, waiting for one in progress to finish
+func stopAutoBackup() {
+	autoBackupMu.Lock()
+	defer autoBackupMu.Unlock()
+
+	if autoBackupStop == nil {
+		return
+	}
+	close(autoBackupStop)
+	<-autoBackupDone
+	autoBackupStop, autoBackupDone = nil, nil
+}
+
+// exportJSONFile writes exportJSON's output to path, replacing any
+// existing file only once the new one is complete
+func exportJSONFile(path string) error {
+	return writeFileAtomic(path, exportJSON)
+}
+
+// writeFileAtomic writes to a temporary file beside path and renames it
+// into place on success, so a failed write never clobbers the old file
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+
+	if err := write(file); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// pruneBackups removes the oldest backups beyond maxBackups, ordering them
+// by the timestamp in their file names
+func pruneBackups() error {
+	if maxBackups <= 0 {
+		return nil
+	}
+	backups, err := listBackups()
+	if err != nil {
+		return err
+	}
+	for len(backups) > maxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// listBackups returns the backup files in backupPath, oldest first
+// Files whose names don't carry a valid timestamp are ignored
+func listBackups() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(backupPath, "backup_*.json*"))
+	if err != nil {
+		return nil, err
+	}
+
+	stamps := make(map[string]time.Time)
+	var backups []string
+	for _, path := range matches {
+		name := strings.TrimSuffix(filepath.Base(path), ".gz")
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, "backup_"), ".json")
+		t, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		stamps[path] = t
+		backups = append(backups, path)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return stamps[backups[i]].Before(stamps[backups[j]])
+	})
+	return backups, nil
+}
+
+// restoreBackup replaces the dataset with the contents of a backup file,
+// decompressing it first if the name ends in .gz
+func restoreBackup(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading backup: %w", err)
+	}
+	if strings.HasSuffix(path, ".gz") {
+		decompressor, err := gzip.NewReader(bytes.NewReader(content))
+		if err != nil {
+			return fmt.Errorf("decompressing backup %s: %w", path, err)
+		}
+		content, err = io.ReadAll(decompressor)
+		if err != nil {
+			return fmt.Errorf("decompressing backup %s: %w", path, err)
+		}
+	}
+
+	var envelope struct {
+		Metadata *MetricsData `json:"metadata"`
+		Data     *[]DataItem  `json:"data"`
+	}
+	if err := json.Unmarshal(content, &envelope); err != nil {
+		return fmt.Errorf("parsing backup %s: %w", path, err)
+	}
+	if envelope.Metadata == nil || envelope.Data == nil {
+		return fmt.Errorf("backup %s is missing its metadata or data section", path)
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+	dataset = *envelope.Data
+	undoStack, redoStack, importStack = nil, nil, nil
+	// Trashed items belonged to the dataset being replaced
+	trash = nil
+	datasetChanged()
+	return nil
+}
+
+// discardChanges restores the most recent backup in backupPath, dropping
+// everything done since it was taken
+func discardChanges() (string, error) {
+	backups, err := listBackups()
+	if err != nil {
+		return "", err
+	}
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups found in %s to restore", backupPath)
+	}
+	latest := backups[len(backups)-1]
+	return latest, restoreBackup(latest)
+}
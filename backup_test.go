@@ -0,0 +1,258 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// useBackupDir points backupPath at a fresh directory for the test
+func useBackupDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	previous := backupPath
+	backupPath = dir
+	t.Cleanup(func() { backupPath = previous })
+	return dir
+}
+
+// writeBackupFiles creates empty files with the given names in dir
+func writeBackupFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSetBackupPath(t *testing.T) {
+	useBackupDir(t)
+	root := t.TempDir()
+	file := filepath.Join(root, "file")
+	writeBackupFiles(t, root, "file")
+	readOnly := filepath.Join(root, "read-only")
+	if err := os.Mkdir(readOnly, 0500); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(readOnly, 0700) })
+
+	tests := []struct {
+		name     string
+		path     string
+		readOnly bool
+		wantErr  string
+	}{
+		{name: "created when missing", path: filepath.Join(root, "a", "b")},
+		{name: "existing directory", path: root},
+		{name: "below a file", path: filepath.Join(file, "sub"), wantErr: "backup path"},
+		{name: "read-only directory", path: readOnly, readOnly: true,
+			wantErr: "permission denied writing to the directory"},
+		{name: "below a read-only directory", path: filepath.Join(readOnly, "sub"), readOnly: true,
+			wantErr: "permission denied creating the directory"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.readOnly && os.Geteuid() == 0 {
+				t.Skip("root ignores directory permissions")
+			}
+			before := backupPath
+			err := setBackupPath(tt.path)
+			if (err != nil) != (tt.wantErr != "") || err != nil && !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				if backupPath != before {
+					t.Errorf("failed call changed backupPath to %s", backupPath)
+				}
+				return
+			}
+			if backupPath != tt.path || !filepath.IsAbs(backupPath) {
+				t.Errorf("backupPath = %s, want %s", backupPath, tt.path)
+			}
+			if probes, _ := filepath.Glob(filepath.Join(tt.path, ".write-check*")); len(probes) > 0 {
+				t.Errorf("write check left files behind: %v", probes)
+			}
+		})
+	}
+}
+
+func TestBackupRestore(t *testing.T) {
+	for _, compress := range []bool{false, true} {
+		name := "plain"
+		if compress {
+			name = "gzip"
+		}
+		t.Run(name, func(t *testing.T) {
+			useBackupDir(t)
+			useDataset(t, threeItems()...)
+			compressBackups = compress
+			defer func() { compressBackups = false }()
+
+			path, err := createBackup()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.HasSuffix(path, ".gz") != compress {
+				t.Errorf("backup %s, compress %v", path, compress)
+			}
+
+			want := labelsOf()
+			setLabel(0, "changed")
+			deleteItem(1)
+			if err := restoreBackup(path); err != nil {
+				t.Fatal(err)
+			}
+			if got := labelsOf(); !reflect.DeepEqual(got, want) {
+				t.Errorf("labels after restore = %v, want %v", got, want)
+			}
+			if len(undoStack) != 0 || len(trash) != 0 {
+				t.Errorf("restore kept %d undo entries and %d trashed items", len(undoStack), len(trash))
+			}
+		})
+	}
+}
+
+func TestRestoreBackupRejects(t *testing.T) {
+	dir := useBackupDir(t)
+	tests := []struct {
+		name    string
+		file    string
+		content string
+	}{
+		{"not JSON", "backup_bad.json", "{"},
+		{"missing data", "backup_empty.json", `{"metadata": {}}`},
+		{"bad gzip", "backup_bad.json.gz", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			path := filepath.Join(dir, tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := restoreBackup(path); err == nil {
+				t.Fatal("restore succeeded")
+			}
+			if len(dataset) != 3 {
+				t.Errorf("failed restore left %d items", len(dataset))
+			}
+		})
+	}
+}
+
+func TestListAndPruneBackups(t *testing.T) {
+	dir := useBackupDir(t)
+	writeBackupFiles(t, dir,
+		"backup_20240102_000000.000.json",
+		"backup_20231231_235959.999.json.gz",
+		"backup_20240101_120000.500.json",
+		"backup_latest.json",
+		"notes.txt",
+	)
+	backups, err := listBackups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, path := range backups {
+		names = append(names, filepath.Base(path))
+	}
+	want := []string{
+		"backup_20231231_235959.999.json.gz",
+		"backup_20240101_120000.500.json",
+		"backup_20240102_000000.000.json",
+	}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("listBackups = %v, want %v", names, want)
+	}
+
+	maxBackups = 2
+	defer func() { maxBackups = 0 }()
+	if err := pruneBackups(); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ = listBackups()
+	if len(backups) != 2 || filepath.Base(backups[0]) != want[1] {
+		t.Errorf("after pruning: %v", backups)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "backup_latest.json")); err != nil {
+		t.Errorf("pruning removed an unrelated file: %v", err)
+	}
+}
+
+func TestDiscardChanges(t *testing.T) {
+	useBackupDir(t)
+	useDataset(t, threeItems()...)
+	if _, err := discardChanges(); err == nil {
+		t.Fatal("discard without backups succeeded")
+	}
+
+	path, err := createBackup()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := labelsOf()
+	setLabel(2, "changed")
+	restored, err := discardChanges()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored != path {
+		t.Errorf("restored %s, want %s", restored, path)
+	}
+	if got := labelsOf(); !reflect.DeepEqual(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+}
+
+func TestWriteFileAtomicKeepsOldFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	failure := errors.New("disk full")
+	err := writeFileAtomic(path, func(w io.Writer) error {
+		w.Write([]byte("partial"))
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Fatalf("err = %v, want %v", err, failure)
+	}
+	if content, _ := os.ReadFile(path); string(content) != "old" {
+		t.Errorf("file = %q, want old", content)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temporary file left behind: %v", entries)
+	}
+}
+
+func TestAutoBackup(t *testing.T) {
+	useBackupDir(t)
+	useDataset(t, threeItems()...)
+	startAutoBackup(5 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		backups, err := listBackups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(backups) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			stopAutoBackup()
+			t.Fatal("no backup written")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	stopAutoBackup()
+	// Stopping twice is harmless
+	stopAutoBackup()
+}
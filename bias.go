@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Datasets at least this large have their bias counts split across workers
+var parallelBiasThreshold = 10000
+
+// BiasReport holds the numbers behind detectSignificantBias
+type BiasReport struct {
+	DistributionBias float64            `json:"distribution_bias"` // largest minus smallest label share
+	LabelShare       map[string]float64 `json:"label_share"`       // fraction of labeled items per label
+	AvgTextLength    map[string]float64 `json:"avg_text_length"`   // mean text length in characters per label
+	TextLengthRatio  float64            `json:"text_length_ratio"` // longest average length over the shortest
+}
+
+// biasVerifiedOnly limits biasReport to human-verified items, leaving out
+// auto-accepted and unreviewed labels
+var biasVerifiedOnly bool
+
+// biasReport measures label imbalance and per-label text length differences
+// over labeled items
+func biasReport() BiasReport {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if biasVerifiedOnly {
+		return biasReportFor(verifiedItems())
+	}
+	return biasReportFor(dataset)
+}
+
+func biasReportFor(items []DataItem) BiasReport {
+	var counts, lengths map[string]int
+	var total int
+	if len(items) >= parallelBiasThreshold {
+		counts, lengths, total = labelTextCountsParallel(items)
+	} else {
+		counts, lengths, total = labelTextCounts(items)
+	}
+
+	report := BiasReport{
+		LabelShare:    make(map[string]float64),
+		AvgTextLength: make(map[string]float64),
+	}
+	if total == 0 {
+		return report
+	}
+
+	minShare, maxShare := 1.0, 0.0
+	minLength, maxLength := -1.0, 0.0
+	for label, count := range counts {
+		share := float64(count) / float64(total)
+		report.LabelShare[label] = share
+		if share < minShare {
+			minShare = share
+		}
+		if share > maxShare {
+			maxShare = share
+		}
+
+		avg := float64(lengths[label]) / float64(count)
+		report.AvgTextLength[label] = avg
+		if minLength < 0 || avg < minLength {
+			minLength = avg
+		}
+		if avg > maxLength {
+			maxLength = avg
+		}
+	}
+	report.DistributionBias = maxShare - minShare
+	if minLength > 0 {
+		report.TextLengthRatio = maxLength / minLength
+	}
+	return report
+}
+
+// labelTextCounts tallies items and text length in characters per label,
+// skipping unlabeled items
+func labelTextCounts(items []DataItem) (counts, lengths map[string]int, total int) {
+	counts = make(map[string]int)
+	lengths = make(map[string]int)
+	for _, item := range items {
+		if item.Label == "" {
+			continue
+		}
+		counts[item.Label]++
+		lengths[item.Label] += utf8.RuneCountInString(item.Text)
+		total++
+	}
+	return counts, lengths, total
+}
+
+// labelTextCountsParallel splits items into one chunk per CPU and sums the
+// partial tallies, which gives the same result as labelTextCounts because
+// only integers are added
+func labelTextCountsParallel(items []DataItem) (counts, lengths map[string]int, total int) {
+	workers := runtime.NumCPU()
+	chunk := (len(items) + workers - 1) / workers
+	if chunk == 0 {
+		return labelTextCounts(items)
+	}
+
+	type partial struct {
+		counts, lengths map[string]int
+		total           int
+	}
+	var partials []partial
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for start := 0; start < len(items); start += chunk {
+		end := start + chunk
+		if end > len(items) {
+			end = len(items)
+		}
+		wg.Add(1)
+		go func(part []DataItem) {
+			defer wg.Done()
+			c, l, t := labelTextCounts(part)
+			mu.Lock()
+			partials = append(partials, partial{c, l, t})
+			mu.Unlock()
+		}(items[start:end])
+	}
+	wg.Wait()
+
+	counts = make(map[string]int)
+	lengths = make(map[string]int)
+	for _, p := range partials {
+		for label, count := range p.counts {
+			counts[label] += count
+			lengths[label] += p.lengths[label]
+		}
+		total += p.total
+	}
+	return counts, lengths, total
+}
+
+// distributionBiasThreshold is the DistributionBias above which
+// biasWarnings reports a skewed label distribution
+var distributionBiasThreshold = 0.3
+
+// textLengthBiasRatio is the TextLengthRatio above which biasWarnings
+// reports that text length varies by label
+var textLengthBiasRatio = 2.0
+
+// detectSignificantBias describes any imbalance in the bias report worth
+// flagging, or returns an empty string
+func detectSignificantBias() string {
+	return strings.Join(biasWarnings(biasReport()), "\n")
+}
+
+func biasWarnings(report BiasReport) []string {
+	var warnings []string
+	if report.DistributionBias > distributionBiasThreshold {
+		warnings = append(warnings, fmt.Sprintf(
+			"Label distribution is skewed: shares differ by %.0f%%", report.DistributionBias*100))
+	}
+	if report.TextLengthRatio > textLengthBiasRatio {
+		warnings = append(warnings, fmt.Sprintf(
+			"Text length varies by label: longest average is %.1fx the shortest", report.TextLengthRatio))
+	}
+	return warnings
+}
+
+// exportBiasReport writes the bias report and any warnings about it as JSON
+func exportBiasReport(writer io.Writer) error {
+	report := biasReport()
+	warnings := biasWarnings(report)
+	if warnings == nil {
+		warnings = []string{}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(struct {
+		BiasReport
+		Warnings []string `json:"warnings"`
+	}{report, warnings})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBiasReport(t *testing.T) {
+	items := []DataItem{
+		{ID: 1, Text: "aaaa", Label: "x", UserVerified: true},
+		{ID: 2, Text: "aaaa", Label: "x"},
+		{ID: 3, Text: "aaaa", Label: "x"},
+		{ID: 4, Text: "ü", Label: "y", UserVerified: true},
+		{ID: 5, Text: "unlabeled"},
+	}
+	tests := []struct {
+		name         string
+		verifiedOnly bool
+		wantShare    map[string]float64
+		wantLength   map[string]float64
+		wantBias     float64
+		wantRatio    float64
+	}{
+		{"all labeled", false, map[string]float64{"x": 0.75, "y": 0.25}, map[string]float64{"x": 4, "y": 1}, 0.5, 4},
+		{"verified only", true, map[string]float64{"x": 0.5, "y": 0.5}, map[string]float64{"x": 4, "y": 1}, 0, 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, items...)
+			biasVerifiedOnly = tt.verifiedOnly
+			defer func() { biasVerifiedOnly = false }()
+
+			report := biasReport()
+			if !reflect.DeepEqual(report.LabelShare, tt.wantShare) || !reflect.DeepEqual(report.AvgTextLength, tt.wantLength) {
+				t.Errorf("shares %v, lengths %v", report.LabelShare, report.AvgTextLength)
+			}
+			if !approxEqual(report.DistributionBias, tt.wantBias) || !approxEqual(report.TextLengthRatio, tt.wantRatio) {
+				t.Errorf("bias %v, ratio %v, want %v, %v", report.DistributionBias, report.TextLengthRatio, tt.wantBias, tt.wantRatio)
+			}
+		})
+	}
+
+	useDataset(t, DataItem{ID: 1, Text: "unlabeled"})
+	if report := biasReport(); report.DistributionBias != 0 || len(report.LabelShare) != 0 {
+		t.Errorf("unlabeled dataset report = %+v", report)
+	}
+}
+
+func TestLabelTextCountsParallel(t *testing.T) {
+	var items []DataItem
+	for i := 0; i < 1001; i++ {
+		item := DataItem{ID: i + 1, Text: strings.Repeat("é", i%7)}
+		if i%5 != 0 {
+			item.Label = fmt.Sprint("label", i%3)
+		}
+		items = append(items, item)
+	}
+	for _, n := range []int{0, 1, 3, len(items)} {
+		counts, lengths, total := labelTextCounts(items[:n])
+		pCounts, pLengths, pTotal := labelTextCountsParallel(items[:n])
+		if !reflect.DeepEqual(counts, pCounts) || !reflect.DeepEqual(lengths, pLengths) || total != pTotal {
+			t.Errorf("%d items: parallel %v %v %d, sequential %v %v %d", n, pCounts, pLengths, pTotal, counts, lengths, total)
+		}
+	}
+}
+
+func TestBiasWarnings(t *testing.T) {
+	tests := []struct {
+		name   string
+		report BiasReport
+		want   int
+	}{
+		{"balanced", BiasReport{DistributionBias: 0.3, TextLengthRatio: 2}, 0},
+		{"skewed", BiasReport{DistributionBias: 0.31, TextLengthRatio: 1}, 1},
+		{"lengths vary", BiasReport{DistributionBias: 0, TextLengthRatio: 2.5}, 1},
+		{"both", BiasReport{DistributionBias: 0.9, TextLengthRatio: 3}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := biasWarnings(tt.report); len(got) != tt.want {
+				t.Errorf("warnings = %q, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportBiasReport(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "a", Label: "x"}, DataItem{ID: 2, Text: "a", Label: "y"})
+	var buf bytes.Buffer
+	if err := exportBiasReport(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"distribution_bias", "label_share", "avg_text_length", "text_length_ratio"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("missing %s in %s", key, buf.String())
+		}
+	}
+	if warnings, ok := decoded["warnings"].([]interface{}); !ok || len(warnings) != 0 {
+		t.Errorf("warnings = %v, want []", decoded["warnings"])
+	}
+	if detectSignificantBias() != "" {
+		t.Errorf("balanced dataset flagged: %q", detectSignificantBias())
+	}
+}
+
+func BenchmarkLabelTextCounts(b *testing.B) {
+	items := make([]DataItem, 200000)
+	for i := range items {
+		items[i] = DataItem{
+			Label: fmt.Sprintf("label%d", i%8),
+			Text:  strings.Repeat("word ", i%40+1),
+		}
+	}
+
+	for _, bm := range []struct {
+		name  string
+		count func([]DataItem) (map[string]int, map[string]int, int)
+	}{
+		{"serial", labelTextCounts},
+		{"parallel", labelTextCountsParallel},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bm.count(items)
+			}
+		})
+	}
+}
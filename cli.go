@@ -0,0 +1,159 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stringList is a flag that may be given more than once
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// headlessRequested reports whether args ask for runHeadless instead of the GUI
+func headlessRequested(args []string) bool {
+	for _, arg := range args {
+		switch arg {
+		case "-headless", "--headless", "-headless=true", "--headless=true":
+			return true
+		}
+	}
+	return false
+}
+
+// runHeadless carries out the steps named by args without opening a window
+// and returns the process exit code: 0 on success, 1 when a step fails and
+// 2 for bad arguments
+// Steps run in a fixed order: -load, each -import in turn, -dedup,
+// -export and -save, starting from an empty dataset unless -load is given
+func runHeadless(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("headless", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Bool("headless", true, "run without the GUI")
+	load := flags.String("load", "", "session file to start from")
+	var imports stringList
+	flags.Var(&imports, "import", "file to import: .csv, .tsv, .jsonl, .json or .xlsx (repeatable)")
+	dedup := flags.Bool("dedup", false, "remove items with duplicate text")
+	export := flags.String("export", "", "file to export: .csv, .tsv or .json")
+	save := flags.String("save", "", "session file to write at the end")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(flags.Args(), " "))
+		return 2
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintln(stderr, "error:", err)
+		return 1
+	}
+
+	datasetMu.Lock()
+	dataset = nil
+	auditLog = nil
+	undoStack, redoStack, importStack = nil, nil, nil
+	trash = nil
+	datasetMu.Unlock()
+
+	if *load != "" {
+		if err := loadSession(*load); err != nil {
+			return fail(err)
+		}
+		fmt.Fprintf(stdout, "loaded %s: %d items\n", *load, datasetLen())
+	}
+	for _, path := range imports {
+		before := datasetLen()
+		sanitized, err := importFile(path)
+		if err != nil {
+			return fail(fmt.Errorf("importing %s: %w", path, err))
+		}
+		fmt.Fprintf(stdout, "imported %s: %d items\n", path, datasetLen()-before)
+		if sanitized > 0 {
+			fmt.Fprintf(stdout, "sanitized %d rows\n", sanitized)
+		}
+	}
+	if *dedup {
+		removed, locked := deduplicate()
+		fmt.Fprintf(stdout, "removed %d duplicates\n", removed)
+		if len(locked) > 0 {
+			fmt.Fprintf(stdout, "kept %d locked duplicates: %v\n", len(locked), locked)
+		}
+	}
+	if *export != "" {
+		if err := exportFile(*export); err != nil {
+			return fail(fmt.Errorf("exporting %s: %w", *export, err))
+		}
+		fmt.Fprintf(stdout, "exported %d items to %s\n", datasetLen(), *export)
+	}
+	if *save != "" {
+		if err := saveSession(*save); err != nil {
+			return fail(err)
+		}
+		fmt.Fprintf(stdout, "saved %s\n", *save)
+	}
+	return 0
+}
+
+func datasetLen() int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return len(dataset)
+}
+
+// importFile picks an importer from the file's extension and returns how
+// many rows sanitizeText changed, which only CSV and TSV imports count
+func importFile(path string) (sanitized int, err error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".xlsx" {
+		return 0, importExcel(path)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	switch ext {
+	case ".csv":
+		return importCSV(file)
+	case ".tsv":
+		return importTSV(file)
+	case ".jsonl":
+		return 0, importJSONL(file)
+	case ".json":
+		return 0, importJSON(file, false)
+	default:
+		return 0, fmt.Errorf("unsupported import format %q", ext)
+	}
+}
+
+// exportFile picks an exporter from the file's extension and writes the
+// file atomically
+func exportFile(path string) error {
+	var export func(io.Writer) error
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		export = exportCSV
+	case ".tsv":
+		export = exportTSV
+	case ".json":
+		export = exportJSON
+	default:
+		return fmt.Errorf("unsupported export format %q", filepath.Ext(path))
+	}
+	return writeFileAtomic(path, export)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHeadlessRequested(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-headless"}, true},
+		{[]string{"-import", "a.csv", "--headless"}, true},
+		{[]string{"--headless=true"}, true},
+		{[]string{"-headless=false"}, false},
+		{[]string{"headless"}, false},
+	}
+	for _, tt := range tests {
+		if got := headlessRequested(tt.args); got != tt.want {
+			t.Errorf("headlessRequested(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRunHeadless(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	csvPath := write("in.csv", "text,label\none,a\ntwo,b\none,a\n")
+	jsonlPath := write("in.jsonl", `{"text": "three"}`)
+	badPath := write("in.txt", "text")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantItems  int
+		wantStdout []string
+		wantStderr string
+	}{
+		{
+			name:       "import, dedup and export",
+			args:       []string{"-headless", "-import", csvPath, "-import", jsonlPath, "-dedup", "-export", filepath.Join(dir, "out.csv")},
+			wantItems:  3,
+			wantStdout: []string{"imported " + csvPath + ": 3 items", "removed 1 duplicates", "exported 3 items"},
+		},
+		{
+			name:       "save and load",
+			args:       []string{"-headless", "-import", jsonlPath, "-save", filepath.Join(dir, "session.json")},
+			wantItems:  1,
+			wantStdout: []string{"saved "},
+		},
+		{
+			name:       "unsupported import",
+			args:       []string{"-headless", "-import", badPath},
+			wantCode:   1,
+			wantStderr: "unsupported import format",
+		},
+		{
+			name:       "unsupported export",
+			args:       []string{"-headless", "-export", filepath.Join(dir, "out.xml")},
+			wantCode:   1,
+			wantStderr: "unsupported export format",
+		},
+		{
+			name:       "unknown flag",
+			args:       []string{"-headless", "-bogus"},
+			wantCode:   2,
+			wantStderr: "bogus",
+		},
+		{
+			name:       "stray argument",
+			args:       []string{"-headless", "extra"},
+			wantCode:   2,
+			wantStderr: "unexpected arguments: extra",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			var stdout, stderr bytes.Buffer
+			code := runHeadless(tt.args, &stdout, &stderr)
+			if code != tt.wantCode {
+				t.Fatalf("exit code = %d, want %d; stderr: %s", code, tt.wantCode, stderr.String())
+			}
+			for _, want := range tt.wantStdout {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("stdout = %q, want it to contain %q", stdout.String(), want)
+				}
+			}
+			if !strings.Contains(stderr.String(), tt.wantStderr) {
+				t.Errorf("stderr = %q, want it to contain %q", stderr.String(), tt.wantStderr)
+			}
+			if tt.wantCode == 0 && datasetLen() != tt.wantItems {
+				t.Errorf("dataset has %d items, want %d", datasetLen(), tt.wantItems)
+			}
+		})
+	}
+
+	useDataset(t)
+	var stdout, stderr bytes.Buffer
+	if code := runHeadless([]string{"-headless", "-load", filepath.Join(dir, "session.json")}, &stdout, &stderr); code != 0 {
+		t.Fatalf("loading the saved session failed: %s", stderr.String())
+	}
+	if datasetLen() != 1 || !strings.Contains(stdout.String(), "1 items") {
+		t.Errorf("loaded %d items; stdout %q", datasetLen(), stdout.String())
+	}
+	exported, err := os.ReadFile(filepath.Join(dir, "out.csv"))
+	if err != nil || strings.Count(string(exported), "\n") != 4 {
+		t.Errorf("exported file = %q, %v", exported, err)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"hash/fnv"
+	"image/color"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+)
+
+// categoryColorMap assigns "#rrggbb" colors to categories by name
+// Categories not listed take a color from categoryPalette
+var categoryColorMap = map[string]string{}
+
+// categoryPalette holds the fallback colors, chosen to stay readable on
+// both the light and dark themes
+var categoryPalette = []color.NRGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+	{R: 0xe3, G: 0x77, B: 0xc2, A: 0xff},
+	{R: 0x7f, G: 0x7f, B: 0x7f, A: 0xff},
+	{R: 0xbc, G: 0xbd, B: 0x22, A: 0xff},
+	{R: 0x17, G: 0xbe, B: 0xcf, A: 0xff},
+}
+
+// categoryColor returns the configured color for category, or a palette
+// color picked from a hash of its name so it is the same on every run
+func categoryColor(category string) color.NRGBA {
+	if value, ok := categoryColorMap[category]; ok {
+		if c, err := parseLabelColor(value); err == nil && c.A != 0 {
+			return c
+		}
+	}
+	hash := fnv.New32a()
+	hash.Write([]byte(category))
+	return categoryPalette[hash.Sum32()%uint32(len(categoryPalette))]
+}
+
+// categoryColors returns the color of every category in the dataset
+func categoryColors() map[string]color.NRGBA {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	colors := make(map[string]color.NRGBA)
+	for _, item := range dataset {
+		if _, ok := colors[item.Category]; !ok {
+			colors[item.Category] = categoryColor(item.Category)
+		}
+	}
+	return colors
+}
+
+// newCategoryBar draws one colored segment per category, sized by its
+// share of the dataset, in alphabetical order
+func newCategoryBar() fyne.CanvasObject {
+	datasetMu.RLock()
+	counts := make(map[string]int)
+	for _, item := range dataset {
+		counts[item.Category]++
+	}
+	datasetMu.RUnlock()
+
+	categories := make([]string, 0, len(counts))
+	for category := range counts {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+
+	bar := &segmentLayout{}
+	segments := make([]fyne.CanvasObject, len(categories))
+	for i, category := range categories {
+		segments[i] = canvas.NewRectangle(categoryColor(category))
+		bar.weights = append(bar.weights, float32(counts[category]))
+	}
+	return container.New(bar, segments...)
+}
+
+// segmentLayout places objects side by side with widths proportional to weights
+type segmentLayout struct {
+	weights []float32
+}
+
+func (l *segmentLayout) Layout(objects []fyne.CanvasObject, size fyne.Size) {
+	total := float32(0)
+	for _, weight := range l.weights {
+		total += weight
+	}
+	x := float32(0)
+	for i, object := range objects {
+		width := float32(0)
+		if total > 0 && i < len(l.weights) {
+			width = size.Width * l.weights[i] / total
+		}
+		object.Move(fyne.NewPos(x, 0))
+		object.Resize(fyne.NewSize(width, size.Height))
+		x += width
+	}
+}
+
+func (l *segmentLayout) MinSize(objects []fyne.CanvasObject) fyne.Size {
+	return fyne.NewSize(0, 20)
+}
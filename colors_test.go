@@ -0,0 +1,34 @@
+package main
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestCategoryColor(t *testing.T) {
+	categoryColorMap = map[string]string{"news": "#102030", "broken": "blue"}
+	defer func() { categoryColorMap = map[string]string{} }()
+
+	if got, want := categoryColor("news"), (color.NRGBA{0x10, 0x20, 0x30, 0xff}); got != want {
+		t.Errorf("configured color = %v, want %v", got, want)
+	}
+	for _, category := range []string{"broken", "sports", ""} {
+		got := categoryColor(category)
+		if got != categoryColor(category) {
+			t.Errorf("%q color is not stable", category)
+		}
+		found := false
+		for _, c := range categoryPalette {
+			found = found || c == got
+		}
+		if !found {
+			t.Errorf("%q color %v is not from the palette", category, got)
+		}
+	}
+
+	useDataset(t, DataItem{ID: 1, Category: "news"}, DataItem{ID: 2, Category: "sports"}, DataItem{ID: 3, Category: "news"})
+	colors := categoryColors()
+	if len(colors) != 2 || colors["news"] != categoryColor("news") {
+		t.Errorf("categoryColors = %v", colors)
+	}
+}
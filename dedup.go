@@ -0,0 +1,251 @@
+package main
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// dedupIgnoreCase makes deduplicate treat texts differing only in case as equal
+var dedupIgnoreCase bool
+
+// normalizeForDedup returns the key two items must share to be duplicates
+func normalizeForDedup(text string) string {
+	text = strings.TrimSpace(text)
+	if dedupIgnoreCase {
+		text = strings.ToLower(text)
+	}
+	return text
+}
+
+// deduplicate removes items whose normalized text matches an earlier item,
+// keeping the first occurrence, and returns how many were removed
+// Duplicates locked by another user are kept and their IDs returned
+func deduplicate() (removed int, locked []int) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	duplicates, locked := duplicateIndices()
+	if len(duplicates) == 0 {
+		return 0, locked
+	}
+
+	now := time.Now()
+	kept := dataset[:0]
+	next := 0
+	for i, item := range dataset {
+		if next < len(duplicates) && duplicates[next] == i {
+			recordChange(ChangeRecord{
+				ItemID:    item.ID,
+				Field:     "deleted",
+				OldValue:  item,
+				Timestamp: now,
+			})
+			retireID(item.ID)
+			next++
+			continue
+		}
+		kept = append(kept, item)
+	}
+
+	dataset = kept
+	// Indices recorded for undo no longer line up with the dataset
+	undoStack, redoStack = nil, nil
+	datasetChanged()
+	return len(duplicates), locked
+}
+
+// previewDeduplicate returns the indices deduplicate would remove, in order,
+// without changing the dataset
+func previewDeduplicate() []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	duplicates, _ := duplicateIndices()
+	return duplicates
+}
+
+// duplicateIndices returns the indices of the items deduplicate removes and,
+// apart, the IDs of duplicates locked by another user
+func duplicateIndices() (duplicates, locked []int) {
+	seen := make(map[string]bool)
+	for i, item := range dataset {
+		key := normalizeForDedup(item.Text)
+		if !seen[key] {
+			seen[key] = true
+			continue
+		}
+		if checkUnlocked(i, currentUser) != nil {
+			locked = append(locked, item.ID)
+			continue
+		}
+		duplicates = append(duplicates, i)
+	}
+	return duplicates, locked
+}
+
+// findNearDuplicates groups items whose normalized texts have a Levenshtein
+// similarity above threshold, from 0 (nothing alike) to 1 (identical)
+// Each group lists indices in dataset order and has at least two items;
+// similar items chain, so a group can hold texts that differ more
+// Empty texts are left to deduplicate
+func findNearDuplicates(threshold float64) [][]int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	texts := make([][]rune, len(dataset))
+	sorted := make([][]rune, len(dataset))
+	order := make([]int, len(dataset))
+	for i, item := range dataset {
+		texts[i] = []rune(normalizeForDedup(item.Text))
+		sorted[i] = append([]rune(nil), texts[i]...)
+		sort.Slice(sorted[i], func(a, b int) bool { return sorted[i][a] < sorted[i][b] })
+		order[i] = i
+	}
+	// Sorting by length bounds the similarity of later pairs, since
+	// shorter/longer can only fall as the longer text grows
+	sort.SliceStable(order, func(a, b int) bool {
+		return len(texts[order[a]]) < len(texts[order[b]])
+	})
+
+	parent := make([]int, len(dataset))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for a, i := range order {
+		for _, j := range order[a+1:] {
+			shorter, longer := len(texts[i]), len(texts[j])
+			if longer > 0 && float64(shorter)/float64(longer) <= threshold {
+				break
+			}
+			// Similarity above threshold allows at most limit edits
+			limit := int(math.Ceil((1-threshold)*float64(longer))) - 1
+			if bagDistance(sorted[i], sorted[j]) > limit {
+				continue
+			}
+			if editDistanceWithin(texts[i], texts[j], limit) <= limit {
+				ri, rj := find(i), find(j)
+				if ri != rj {
+					parent[ri] = rj
+				}
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range dataset {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	var groups [][]int
+	for _, group := range members {
+		if len(group) > 1 {
+			groups = append(groups, group)
+		}
+	}
+	sort.Slice(groups, func(a, b int) bool { return groups[a][0] < groups[b][0] })
+	return groups
+}
+
+// bagDistance is a cheap lower bound on the edit distance between two texts,
+// given their runes in sorted order: the larger count of runes one has that
+// the other lacks
+func bagDistance(a, b []rune) int {
+	onlyA, onlyB := 0, 0
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			i++
+			j++
+		case a[i] < b[j]:
+			onlyA++
+			i++
+		default:
+			onlyB++
+			j++
+		}
+	}
+	onlyA += len(a) - i
+	onlyB += len(b) - j
+	if onlyA > onlyB {
+		return onlyA
+	}
+	return onlyB
+}
+
+// editDistanceWithin returns the Levenshtein distance between a and b, or
+// limit+1 as soon as it is certain to exceed limit
+// Only cells within limit of the diagonal can stay under it, so the rest
+// are skipped
+func editDistanceWithin(a, b []rune, limit int) int {
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	if len(a)-len(b) > limit {
+		return limit + 1
+	}
+	over := limit + 1
+
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+		if j > limit {
+			previous[j] = over
+		}
+	}
+	for i := 1; i <= len(a); i++ {
+		from, to := i-limit, i+limit
+		if from < 1 {
+			from = 1
+		}
+		if to > len(b) {
+			to = len(b)
+		}
+		current[0] = over
+		if i <= limit {
+			current[0] = i
+		}
+		if from > 1 {
+			current[from-1] = over
+		}
+		rowMin := current[0]
+		for j := from; j <= to; j++ {
+			best := previous[j-1]
+			if a[i-1] != b[j-1] {
+				best++
+			}
+			if previous[j]+1 < best {
+				best = previous[j] + 1
+			}
+			if current[j-1]+1 < best {
+				best = current[j-1] + 1
+			}
+			if best > over {
+				best = over
+			}
+			current[j] = best
+			if best < rowMin {
+				rowMin = best
+			}
+		}
+		if to < len(b) {
+			current[to+1] = over
+		}
+		if rowMin > limit {
+			return over
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeduplicate(t *testing.T) {
+	tests := []struct {
+		name        string
+		ignoreCase  bool
+		texts       []string
+		lockIndex   int
+		wantRemoved int
+		wantLocked  []int
+		wantIDs     []int
+	}{
+		{
+			name:        "keeps first occurrence",
+			texts:       []string{"a", " a ", "b", "a"},
+			lockIndex:   -1,
+			wantRemoved: 2,
+			wantIDs:     []int{1, 3},
+		},
+		{
+			name:        "case differs",
+			texts:       []string{"Hello", "hello"},
+			lockIndex:   -1,
+			wantRemoved: 0,
+			wantIDs:     []int{1, 2},
+		},
+		{
+			name:        "case ignored",
+			ignoreCase:  true,
+			texts:       []string{"Hello", "hello"},
+			lockIndex:   -1,
+			wantRemoved: 1,
+			wantIDs:     []int{1},
+		},
+		{
+			name:        "locked duplicate kept",
+			texts:       []string{"a", "a", "a"},
+			lockIndex:   1,
+			wantRemoved: 1,
+			wantLocked:  []int{2},
+			wantIDs:     []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []DataItem
+			for i, text := range tt.texts {
+				items = append(items, DataItem{ID: i + 1, Text: text})
+			}
+			useDataset(t, items...)
+			dedupIgnoreCase = tt.ignoreCase
+			defer func() { dedupIgnoreCase = false }()
+			if tt.lockIndex >= 0 {
+				if err := lockItem(tt.lockIndex, "alice"); err != nil {
+					t.Fatal(err)
+				}
+				currentUser = "bob"
+			}
+
+			before := ids()
+			preview := previewDeduplicate()
+			if len(preview) != tt.wantRemoved {
+				t.Errorf("preview = %v, want %d indices", preview, tt.wantRemoved)
+			}
+			if got := ids(); !reflect.DeepEqual(got, before) || len(auditLog) != 0 {
+				t.Errorf("preview changed the dataset: IDs %v, %d audit entries", got, len(auditLog))
+			}
+			removed, locked := deduplicate()
+			if removed != tt.wantRemoved || !reflect.DeepEqual(locked, tt.wantLocked) {
+				t.Errorf("deduplicate = %d, %v, want %d, %v", removed, locked, tt.wantRemoved, tt.wantLocked)
+			}
+			if got := ids(); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", got, tt.wantIDs)
+			}
+			deleted := 0
+			for _, change := range auditLog {
+				if change.Field == "deleted" {
+					deleted++
+				}
+			}
+			if deleted != tt.wantRemoved {
+				t.Errorf("audit log has %d deletions, want %d", deleted, tt.wantRemoved)
+			}
+		})
+	}
+}
+
+func TestFindNearDuplicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		texts     []string
+		threshold float64
+		want      [][]int
+	}{
+		{"one edit", []string{"kitten sat", "kitten sit", "dog"}, 0.8, [][]int{{0, 1}}},
+		{"below threshold", []string{"kitten", "sitting"}, 0.8, nil},
+		{"chained", []string{"abcdefghij", "abcdefghiX", "abcdefghYX", "zzz"}, 0.85, [][]int{{0, 1, 2}}},
+		{"identical", []string{"same", "other", "same"}, 0.99, [][]int{{0, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var items []DataItem
+			for i, text := range tt.texts {
+				items = append(items, DataItem{ID: i + 1, Text: text})
+			}
+			useDataset(t, items...)
+			if got := findNearDuplicates(tt.threshold); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findNearDuplicates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditDistanceWithin(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"kitten", "sitting", 5, 3},
+		{"kitten", "sitting", 2, 3},
+		{"", "abc", 3, 3},
+		{"abc", "abc", 0, 0},
+		{"ab", "abcdef", 2, 3},
+	}
+	for _, tt := range tests {
+		if got := editDistanceWithin([]rune(tt.a), []rune(tt.b), tt.limit); got != tt.want {
+			t.Errorf("editDistanceWithin(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// datasetEnvelope is the JSON layout written by exportJSON and createBackup
+type datasetEnvelope struct {
+	Metadata MetricsData `json:"metadata"`
+	Data     []DataItem  `json:"data"`
+}
+
+// exportJSON writes the dataset and its current metrics as a single JSON document
+func exportJSON(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return writeEnvelope(writer, dataset)
+}
+
+// exportVerifiedJSON is exportJSON restricted to human-verified items,
+// with metrics computed over just those items
+func exportVerifiedJSON(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return writeEnvelope(writer, verifiedItems())
+}
+
+// exportTaggedJSON is exportJSON restricted to items carrying tag,
+// with metrics computed over just those items
+// An unused tag exports an empty dataset
+func exportTaggedJSON(writer io.Writer, tag string) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	items := []DataItem{}
+	for _, item := range dataset {
+		if containsString(item.Tags, tag) {
+			items = append(items, item)
+		}
+	}
+	return writeEnvelope(writer, items)
+}
+
+func writeEnvelope(writer io.Writer, items []DataItem) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(datasetEnvelope{
+		Metadata: metricsFor(items),
+		Data:     items,
+	})
+}
+
+// exportJSONStream writes the same bytes as exportJSON but encodes items
+// one at a time, so the output is never held in memory as a whole
+func exportJSONStream(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	out := bufio.NewWriter(writer)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	// Matches the nesting writeEnvelope's indentation gives each part
+	encode := func(value interface{}, prefix string) error {
+		buf.Reset()
+		encoder.SetIndent(prefix, "  ")
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		_, err := out.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+		return err
+	}
+
+	out.WriteString("{\n  \"metadata\": ")
+	if err := encode(metricsFor(dataset), "  "); err != nil {
+		return err
+	}
+	out.WriteString(",\n  \"data\": ")
+	switch {
+	case dataset == nil:
+		out.WriteString("null")
+	case len(dataset) == 0:
+		out.WriteString("[]")
+	default:
+		out.WriteString("[")
+		for i, item := range dataset {
+			if i > 0 {
+				out.WriteString(",")
+			}
+			out.WriteString("\n    ")
+			if err := encode(item, "    "); err != nil {
+				return err
+			}
+		}
+		out.WriteString("\n  ]")
+	}
+	out.WriteString("\n}\n")
+	return out.Flush()
+}
+
+// exportSchema writes a JSON Schema for the document exportJSON produces,
+// derived from datasetEnvelope so it follows any change to the structs
+func exportSchema(writer io.Writer) error {
+	schema := jsonSchemaFor(reflect.TypeOf(datasetEnvelope{}))
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = "Dataset export"
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(schema)
+}
+
+// jsonSchemaFor describes how encoding/json renders values of type t
+// Nil slices and maps encode as null, so those accept null too
+func jsonSchemaFor(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{
+			"type":  []string{"array", "null"},
+			"items": jsonSchemaFor(t.Elem()),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 []string{"object", "null"},
+			"additionalProperties": jsonSchemaFor(t.Elem()),
+		}
+	case reflect.Ptr:
+		return jsonSchemaFor(t.Elem())
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		required := []string{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" && options == "" {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			properties[name] = jsonSchemaFor(field.Type)
+			if !strings.Contains(options, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		return map[string]interface{}{
+			"type":                 "object",
+			"properties":           properties,
+			"required":             required,
+			"additionalProperties": false,
+		}
+	default:
+		// interface{} and anything else may hold any value
+		return map[string]interface{}{}
+	}
+}
+
+// csvQuoteAll makes exportCSV and exportTSV quote every field, not only
+// those containing delimiters, quotes or newlines
+var csvQuoteAll bool
+
+// csvUseCRLF makes exportCSV and exportTSV end rows with \r\n for Windows tools
+var csvUseCRLF bool
+
+// exportCSV writes the dataset in the column layout read by importCSV
+// Prediction columns are the sorted union of labels across all items
+func exportCSV(writer io.Writer) error {
+	return exportDelimited(writer, ',')
+}
+
+// exportTSV is exportCSV with tab-separated columns
+// Fields containing tabs, quotes or newlines are quoted
+func exportTSV(writer io.Writer) error {
+	return exportDelimited(writer, '\t')
+}
+
+// exportVerifiedCSV is exportCSV restricted to human-verified items
+func exportVerifiedCSV(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return writeDelimited(writer, ',', verifiedItems())
+}
+
+// verifiedItems returns the items with UserVerified set; callers hold datasetMu
+func verifiedItems() []DataItem {
+	items := []DataItem{}
+	for _, item := range dataset {
+		if item.UserVerified {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func exportDelimited(writer io.Writer, delimiter rune) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return writeDelimited(writer, delimiter, dataset)
+}
+
+func writeDelimited(writer io.Writer, delimiter rune, items []DataItem) error {
+	predLabels := make(map[string]bool)
+	for _, item := range items {
+		for label := range item.ModelPreds {
+			predLabels[label] = true
+		}
+	}
+	sortedPreds := make([]string, 0, len(predLabels))
+	for label := range predLabels {
+		sortedPreds = append(sortedPreds, label)
+	}
+	sort.Strings(sortedPreds)
+
+	headers := []string{"id", "text", "category", "label", "tags", "confidence"}
+	for _, label := range sortedPreds {
+		headers = append(headers, "pred_"+label)
+	}
+
+	csvWriter := newRecordWriter(writer, delimiter)
+	if err := csvWriter.Write(headers); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		record := []string{
+			strconv.Itoa(item.ID),
+			item.Text,
+			item.Category,
+			item.Label,
+			strings.Join(item.Tags, ","),
+			strconv.FormatFloat(item.Confidence, 'f', -1, 64),
+		}
+		for _, label := range sortedPreds {
+			if score, ok := item.ModelPreds[label]; ok {
+				record = append(record, strconv.FormatFloat(score, 'f', -1, 64))
+			} else {
+				record = append(record, "")
+			}
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+// recordWriter is the part of csv.Writer that writeDelimited uses
+type recordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
+// newRecordWriter returns a csv.Writer honouring csvUseCRLF, or a
+// quotedWriter when csvQuoteAll is set, since csv.Writer can't force quotes
+func newRecordWriter(writer io.Writer, delimiter rune) recordWriter {
+	if csvQuoteAll {
+		return &quotedWriter{writer: bufio.NewWriter(writer), comma: delimiter, useCRLF: csvUseCRLF}
+	}
+	csvWriter := csv.NewWriter(writer)
+	csvWriter.Comma = delimiter
+	csvWriter.UseCRLF = csvUseCRLF
+	return csvWriter
+}
+
+// quotedWriter writes CSV records with every field quoted and embedded
+// quotes doubled
+type quotedWriter struct {
+	writer  *bufio.Writer
+	comma   rune
+	useCRLF bool
+	err     error
+}
+
+func (w *quotedWriter) Write(record []string) error {
+	var line strings.Builder
+	for i, field := range record {
+		if i > 0 {
+			line.WriteRune(w.comma)
+		}
+		line.WriteByte('"')
+		line.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		line.WriteByte('"')
+	}
+	if w.useCRLF {
+		line.WriteString("\r\n")
+	} else {
+		line.WriteByte('\n')
+	}
+	_, err := w.writer.WriteString(line.String())
+	return err
+}
+
+func (w *quotedWriter) Flush() {
+	w.err = w.writer.Flush()
+}
+
+func (w *quotedWriter) Error() error {
+	return w.err
+}
+
+// recordsJSONHistory makes exportRecordsJSON include each item's audit
+// entries under "history"; items with none leave the key out
+var recordsJSONHistory bool
+
+// flatRecord is one item in the layout written by exportRecordsJSON
+type flatRecord struct {
+	ID           int                `json:"id"`
+	Text         string             `json:"text"`
+	RawText      string             `json:"raw_text"`
+	Category     string             `json:"category"`
+	Label        string             `json:"label"`
+	Labels       []string           `json:"labels"`
+	Tags         []string           `json:"tags"`
+	Confidence   float64            `json:"confidence"`
+	UserVerified bool               `json:"user_verified"`
+	ReviewStatus string             `json:"review_status"`
+	AssignedTo   string             `json:"assigned_to"`
+	Version      int                `json:"version"`
+	ModelPreds   map[string]float64 `json:"model_preds"`
+	LastUpdated  time.Time          `json:"last_updated"`
+	Notes        string             `json:"notes"`
+	History      []flatChange       `json:"history,omitempty"`
+}
+
+// flatChange is one audit entry of a flatRecord
+type flatChange struct {
+	Version   int         `json:"version"`
+	User      string      `json:"user"`
+	Field     string      `json:"field"`
+	OldValue  interface{} `json:"old_value"`
+	NewValue  interface{} `json:"new_value"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+// exportRecordsJSON writes the dataset as a top-level JSON array with one
+// object per item, the layout pandas reads with orient="records"
+// Empty tag and label lists are written as [] and missing predictions as {}
+func exportRecordsJSON(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	history := make(map[int][]flatChange)
+	if recordsJSONHistory {
+		for _, record := range auditLog {
+			history[record.ItemID] = append(history[record.ItemID], flatChange{
+				Version:   record.Version,
+				User:      record.User,
+				Field:     record.Field,
+				OldValue:  record.OldValue,
+				NewValue:  record.NewValue,
+				Timestamp: record.Timestamp,
+			})
+		}
+	}
+
+	records := make([]flatRecord, 0, len(dataset))
+	for _, item := range dataset {
+		record := newFlatRecord(item)
+		record.History = history[item.ID]
+		records = append(records, record)
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// newFlatRecord copies item into a flatRecord without history, writing
+// nil lists and predictions as empty ones
+func newFlatRecord(item DataItem) flatRecord {
+	record := flatRecord{
+		ID:           item.ID,
+		Text:         item.Text,
+		RawText:      item.RawText,
+		Category:     item.Category,
+		Label:        item.Label,
+		Labels:       item.Labels,
+		Tags:         item.Tags,
+		Confidence:   item.Confidence,
+		UserVerified: item.UserVerified,
+		ReviewStatus: item.ReviewStatus,
+		AssignedTo:   item.AssignedTo,
+		Version:      item.Version,
+		ModelPreds:   item.ModelPreds,
+		LastUpdated:  item.LastUpdated,
+		Notes:        item.Notes,
+	}
+	if record.Labels == nil {
+		record.Labels = []string{}
+	}
+	if record.Tags == nil {
+		record.Tags = []string{}
+	}
+	if record.ModelPreds == nil {
+		record.ModelPreds = map[string]float64{}
+	}
+	return record
+}
+
+// huggingFaceRecord is one example in the Hugging Face datasets JSON layout
+type huggingFaceRecord struct {
+	Text  string      `json:"text"`
+	Label interface{} `json:"label"`
+}
+
+// exportHuggingFace writes labeled items as a JSON array of {"text", "label"} objects
+// When labelIDs is non-nil, labels are written as their integer class IDs
+// Unlabeled items are skipped and their count is returned
+func exportHuggingFace(writer io.Writer, labelIDs map[string]int) (int, error) {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	records := []huggingFaceRecord{}
+	skipped := 0
+	for _, item := range dataset {
+		if item.Label == "" {
+			skipped++
+			continue
+		}
+		var label interface{} = item.Label
+		if labelIDs != nil {
+			id, ok := labelIDs[item.Label]
+			if !ok {
+				return skipped, fmt.Errorf("label %q has no class ID", item.Label)
+			}
+			label = id
+		}
+		records = append(records, huggingFaceRecord{Text: item.Text, Label: label})
+	}
+
+	return skipped, json.NewEncoder(writer).Encode(records)
+}
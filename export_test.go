@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// coreFields is what the CSV and TSV layouts carry for an item
+type coreFields struct {
+	Text       string
+	Category   string
+	Label      string
+	Tags       []string
+	Confidence float64
+	ModelPreds map[string]float64
+}
+
+func coreOf(items []DataItem) []coreFields {
+	fields := make([]coreFields, len(items))
+	for i, item := range items {
+		fields[i] = coreFields{item.Text, item.Category, item.Label, item.Tags, item.Confidence, item.ModelPreds}
+	}
+	return fields
+}
+
+func TestDelimitedRoundTrip(t *testing.T) {
+	items := []DataItem{
+		{ID: 1, Text: "plain", Category: "c", Label: "pos", Tags: []string{"a", "b"}, Confidence: 0.5,
+			ModelPreds: map[string]float64{"pos": 0.75, "neg": 0.25}},
+		{ID: 2, Text: "commas, \"quotes\"\nand newlines", Label: "neg", Tags: []string{},
+			ModelPreds: map[string]float64{"neg": 1}},
+		{ID: 3, Text: "tab\tinside", Tags: []string{}, ModelPreds: map[string]float64{}},
+	}
+	tests := []struct {
+		name     string
+		quoteAll bool
+		crlf     bool
+		export   func(*bytes.Buffer) error
+		reimport func(*bytes.Buffer) error
+	}{
+		{"csv", false, false, func(b *bytes.Buffer) error { return exportCSV(b) },
+			func(b *bytes.Buffer) error { _, err := importCSV(b); return err }},
+		{"csv quote all with crlf", true, true, func(b *bytes.Buffer) error { return exportCSV(b) },
+			func(b *bytes.Buffer) error { _, err := importCSV(b); return err }},
+		{"tsv", false, false, func(b *bytes.Buffer) error { return exportTSV(b) },
+			func(b *bytes.Buffer) error { _, err := importTSV(b); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, append([]DataItem(nil), items...)...)
+			csvQuoteAll, csvUseCRLF = tt.quoteAll, tt.crlf
+			defer func() { csvQuoteAll, csvUseCRLF = false, false }()
+
+			var buf bytes.Buffer
+			if err := tt.export(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if tt.crlf && !strings.HasSuffix(buf.String(), "\r\n") {
+				t.Errorf("rows don't end with CRLF: %q", buf.String())
+			}
+			if tt.quoteAll && !strings.HasPrefix(buf.String(), `"id","text"`) {
+				t.Errorf("header isn't quoted: %q", buf.String())
+			}
+
+			useDataset(t)
+			if err := tt.reimport(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if got, want := coreOf(dataset), coreOf(items); !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed items:\n got %+v\nwant %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestFilteredJSONExports(t *testing.T) {
+	items := []DataItem{
+		{ID: 1, Label: "a", Tags: []string{"x"}, UserVerified: true},
+		{ID: 2, Label: "b", Tags: []string{"x", "y"}},
+		{ID: 3, Label: "a", UserVerified: true},
+	}
+	tests := []struct {
+		name     string
+		export   func(*bytes.Buffer) error
+		wantIDs  []int
+		wantSize int
+	}{
+		{"verified", func(b *bytes.Buffer) error { return exportVerifiedJSON(b) }, []int{1, 3}, 2},
+		{"tagged", func(b *bytes.Buffer) error { return exportTaggedJSON(b, "x") }, []int{1, 2}, 2},
+		{"unused tag", func(b *bytes.Buffer) error { return exportTaggedJSON(b, "none") }, []int{}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, items...)
+			var buf bytes.Buffer
+			if err := tt.export(&buf); err != nil {
+				t.Fatal(err)
+			}
+			var envelope datasetEnvelope
+			if err := json.Unmarshal(buf.Bytes(), &envelope); err != nil {
+				t.Fatal(err)
+			}
+			got := []int{}
+			for _, item := range envelope.Data {
+				got = append(got, item.ID)
+			}
+			if !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", got, tt.wantIDs)
+			}
+			if envelope.Metadata.DatasetSize != tt.wantSize {
+				t.Errorf("metadata size = %d, want %d", envelope.Metadata.DatasetSize, tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestExportVerifiedCSV(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "kept", UserVerified: true}, DataItem{ID: 2, Text: "dropped"})
+	var buf bytes.Buffer
+	if err := exportVerifiedCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "kept") || strings.Contains(out, "dropped") {
+		t.Errorf("export = %q", out)
+	}
+}
+
+func TestExportHuggingFace(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Text: "good", Label: "pos"},
+		DataItem{ID: 2, Text: "unlabeled"},
+		DataItem{ID: 3, Text: "bad", Label: "neg"},
+	)
+	tests := []struct {
+		name        string
+		labelIDs    map[string]int
+		want        string
+		wantSkipped int
+		wantErr     bool
+	}{
+		{"string labels", nil, `[{"text":"good","label":"pos"},{"text":"bad","label":"neg"}]`, 1, false},
+		{"integer labels", map[string]int{"neg": 0, "pos": 1}, `[{"text":"good","label":1},{"text":"bad","label":0}]`, 1, false},
+		{"missing class id", map[string]int{"pos": 1}, "", 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			skipped, err := exportHuggingFace(&buf, tt.labelIDs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if skipped != tt.wantSkipped {
+				t.Errorf("skipped = %d, want %d", skipped, tt.wantSkipped)
+			}
+			if !tt.wantErr && strings.TrimSpace(buf.String()) != tt.want {
+				t.Errorf("output = %s, want %s", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// checkSchema reports where value, decoded from JSON, breaks schema; it
+// understands the subset of JSON Schema jsonSchemaFor produces
+func checkSchema(schema map[string]interface{}, value interface{}, path string) []string {
+	var problems []string
+	if types, ok := schema["type"]; ok {
+		var allowed []string
+		switch types := types.(type) {
+		case string:
+			allowed = []string{types}
+		case []interface{}:
+			for _, name := range types {
+				allowed = append(allowed, name.(string))
+			}
+		}
+		actual := "null"
+		switch v := value.(type) {
+		case string:
+			actual = "string"
+		case bool:
+			actual = "boolean"
+		case float64:
+			actual = "number"
+			if v == float64(int64(v)) {
+				actual = "integer"
+			}
+		case []interface{}:
+			actual = "array"
+		case map[string]interface{}:
+			actual = "object"
+		}
+		matched := false
+		for _, name := range allowed {
+			if name == actual || name == "number" && actual == "integer" {
+				matched = true
+			}
+		}
+		if !matched {
+			return append(problems, fmt.Sprintf("%s: %s is not one of %v", path, actual, allowed))
+		}
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, element := range v {
+				problems = append(problems, checkSchema(items, element, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	case map[string]interface{}:
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := v[name.(string)]; !ok {
+				problems = append(problems, fmt.Sprintf("%s: missing %s", path, name))
+			}
+		}
+		for key, element := range v {
+			if property, ok := properties[key].(map[string]interface{}); ok {
+				problems = append(problems, checkSchema(property, element, path+"."+key)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				problems = append(problems, checkSchema(additional, element, path+"."+key)...)
+			} else if schema["additionalProperties"] == false {
+				problems = append(problems, fmt.Sprintf("%s: unexpected %s", path, key))
+			}
+		}
+	}
+	return problems
+}
+
+func TestExportSchemaValidatesExport(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Text: "one", Label: "pos", Tags: []string{"t"}, ModelPreds: map[string]float64{"pos": 1},
+			LastUpdated: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		DataItem{ID: 2, Text: "two"},
+	)
+	var schemaBuf, exportBuf bytes.Buffer
+	if err := exportSchema(&schemaBuf); err != nil {
+		t.Fatal(err)
+	}
+	if err := exportJSON(&exportBuf); err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]interface{}
+	var document interface{}
+	if err := json.Unmarshal(schemaBuf.Bytes(), &schema); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(exportBuf.Bytes(), &document); err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range checkSchema(schema, document, "$") {
+		t.Error(problem)
+	}
+
+	// The checker itself must catch a document the schema doesn't describe
+	document.(map[string]interface{})["extra"] = true
+	if len(checkSchema(schema, document, "$")) == 0 {
+		t.Error("schema accepted an unexpected property")
+	}
+}
+
+func TestExportRecordsJSON(t *testing.T) {
+	tests := []struct {
+		history     bool
+		wantHistory bool
+	}{
+		{false, false},
+		{true, true},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint("history=", tt.history), func(t *testing.T) {
+			useDataset(t, DataItem{ID: 1, Label: "a"}, DataItem{ID: 2})
+			setLabel(0, "b")
+			recordsJSONHistory = tt.history
+			defer func() { recordsJSONHistory = false }()
+
+			var buf bytes.Buffer
+			if err := exportRecordsJSON(&buf); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(strings.TrimSpace(buf.String()), "[") {
+				t.Fatalf("output is not a top-level array: %s", buf.String())
+			}
+			var records []map[string]interface{}
+			if err := json.Unmarshal(buf.Bytes(), &records); err != nil {
+				t.Fatal(err)
+			}
+			if len(records) != 2 {
+				t.Fatalf("got %d records, want 2", len(records))
+			}
+			if tags, ok := records[1]["tags"].([]interface{}); !ok || len(tags) != 0 {
+				t.Errorf("nil tags written as %v, want []", records[1]["tags"])
+			}
+			_, hasHistory := records[0]["history"]
+			if hasHistory != tt.wantHistory {
+				t.Errorf("history present = %v, want %v", hasHistory, tt.wantHistory)
+			}
+			if _, ok := records[1]["history"]; ok {
+				t.Error("item without changes has a history key")
+			}
+		})
+	}
+}
+
+func TestExportJSONStreamMatchesExportJSON(t *testing.T) {
+	updated := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		items []DataItem
+	}{
+		{"nil", nil},
+		{"empty", []DataItem{}},
+		{"populated", []DataItem{
+			{ID: 1, Text: "one <&>", Label: "a", Tags: []string{"x"}, LastUpdated: updated,
+				ModelPreds: map[string]float64{"a": 0.5}},
+			{ID: 2, Text: "two", LastUpdated: updated},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, tt.items...)
+			dataset = tt.items
+			var buffered, streamed bytes.Buffer
+			if err := exportJSON(&buffered); err != nil {
+				t.Fatal(err)
+			}
+			if err := exportJSONStream(&streamed); err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(buffered.Bytes(), streamed.Bytes()) {
+				t.Errorf("stream differs:\n%s\nbuffered:\n%s", streamed.String(), buffered.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// itemHistory returns the audit entries for the item at index, oldest first
+func itemHistory(index int) ([]ChangeRecord, error) {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if index < 0 || index >= len(dataset) {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+
+	records := []ChangeRecord{}
+	for _, record := range auditLog {
+		if record.ItemID == dataset[index].ID {
+			records = append(records, record)
+		}
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+	return records, nil
+}
+
+// rollbackItem restores the fields of the item at index to how they were at
+// the given version by replaying its audit entries backwards, then records
+// the rollback itself in the audit log
+// Deletes, restores and earlier rollbacks change no fields and are skipped
+func rollbackItem(index, version int) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return fmt.Errorf("index %d out of range", index)
+	}
+	current := dataset[index].Version
+	if version < 0 || version >= current {
+		return fmt.Errorf("cannot roll back item at version %d to version %d", current, version)
+	}
+
+	id := dataset[index].ID
+	restored := make(map[string]interface{})
+	earliest := current + 1
+	for i := len(auditLog) - 1; i >= 0; i-- {
+		record := auditLog[i]
+		if record.ItemID != id {
+			continue
+		}
+		switch record.Field {
+		case "rollback", "deleted", "restored", "purged":
+			continue
+		}
+		if record.Version <= version {
+			break
+		}
+		if _, ok := updateFields[record.Field]; !ok {
+			return fmt.Errorf("cannot roll back past a %q change", record.Field)
+		}
+		// Walking backwards, the oldest value seen for a field wins
+		restored[record.Field] = record.OldValue
+		if record.Version < earliest {
+			earliest = record.Version
+		}
+	}
+	if earliest > version+1 {
+		return fmt.Errorf("version %d predates the recorded history of item %d", version, id)
+	}
+
+	itemType := reflect.TypeOf(DataItem{})
+	for key, value := range restored {
+		field, _ := itemType.FieldByName(updateFields[key])
+		converted, err := convertFieldValue(value, field.Type)
+		if err != nil {
+			return fmt.Errorf("restoring %s: %w", key, err)
+		}
+		restored[key] = converted
+	}
+
+	if err := applyUpdates([]int{index}, restored); err != nil {
+		return err
+	}
+	recordChange(ChangeRecord{
+		ItemID:    id,
+		Version:   dataset[index].Version,
+		Field:     "rollback",
+		OldValue:  current,
+		NewValue:  version,
+		Timestamp: dataset[index].LastUpdated,
+	})
+	return nil
+}
+
+// convertFieldValue coerces an audited value into the field's type, since
+// values read back from a saved session arrive as generic JSON types
+func convertFieldValue(value interface{}, fieldType reflect.Type) (interface{}, error) {
+	if value == nil {
+		return reflect.Zero(fieldType).Interface(), nil
+	}
+	if reflect.TypeOf(value) == fieldType {
+		return value, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	converted := reflect.New(fieldType)
+	if err := json.Unmarshal(data, converted.Interface()); err != nil {
+		return nil, err
+	}
+	return converted.Elem().Interface(), nil
+}
+
+// exportAuditLog writes every audit entry, deletions included, as CSV rows
+// in chronological order
+// Values other than strings are written as JSON
+func exportAuditLog(writer io.Writer) error {
+	datasetMu.RLock()
+	records := append([]ChangeRecord(nil), auditLog...)
+	datasetMu.RUnlock()
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Timestamp.Before(records[j].Timestamp)
+	})
+
+	csvWriter := csv.NewWriter(writer)
+	headers := []string{"item_id", "timestamp", "user", "version", "field", "old_value", "new_value"}
+	if err := csvWriter.Write(headers); err != nil {
+		return err
+	}
+	for _, record := range records {
+		oldValue, err := auditValue(record.OldValue)
+		if err != nil {
+			return err
+		}
+		newValue, err := auditValue(record.NewValue)
+		if err != nil {
+			return err
+		}
+		row := []string{
+			strconv.Itoa(record.ItemID),
+			record.Timestamp.Format(time.RFC3339Nano),
+			record.User,
+			strconv.Itoa(record.Version),
+			record.Field,
+			oldValue,
+			newValue,
+		}
+		if err := csvWriter.Write(row); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
+
+func auditValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	default:
+		content, err := json.Marshal(v)
+		return string(content), err
+	}
+}
+
+// formatHistory renders one line per change, such as
+// "2024-01-02 15:04:05  label: "positive" -> "negative""
+func formatHistory(records []ChangeRecord) string {
+	var lines []string
+	for _, record := range records {
+		lines = append(lines, fmt.Sprintf("%s  %s: %s -> %s",
+			record.Timestamp.Format("2006-01-02 15:04:05"),
+			record.Field,
+			formatHistoryValue(record.OldValue),
+			formatHistoryValue(record.NewValue),
+		))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatHistoryValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "(none)"
+	case string:
+		return fmt.Sprintf("%q", v)
+	case DataItem:
+		return fmt.Sprintf("item %d", v.ID)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestItemHistory(t *testing.T) {
+	useDataset(t, threeItems()...)
+	setLabel(1, "x")
+	setLabel(0, "y")
+	setLabel(1, "z")
+
+	records, err := itemHistory(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []interface{}
+	for _, record := range records {
+		if record.ItemID != 2 {
+			t.Errorf("history of item 2 holds %+v", record)
+		}
+		if record.Field == "label" {
+			labels = append(labels, record.NewValue)
+		}
+	}
+	if !reflect.DeepEqual(labels, []interface{}{"x", "z"}) {
+		t.Errorf("label history = %v, want [x z]", labels)
+	}
+	if records, err := itemHistory(2); err != nil || len(records) != 0 || records == nil {
+		t.Errorf("unchanged item history = %v, %v, want empty", records, err)
+	}
+	if _, err := itemHistory(3); err == nil {
+		t.Error("out of range index accepted")
+	}
+}
+
+func TestRollbackItem(t *testing.T) {
+	tests := []struct {
+		name       string
+		version    int
+		wantErr    bool
+		wantLabel  string
+		wantTags   []string
+		wantVerify bool
+	}{
+		{"to the start", 0, false, "a", nil, false},
+		{"to version 1", 1, false, "b", nil, true},
+		{"to version 2", 2, false, "b", []string{"t"}, true},
+		{"current version", 3, true, "c", []string{"t"}, true},
+		{"negative version", -1, true, "c", []string{"t"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, DataItem{ID: 1, Label: "a"})
+			setLabel(0, "b")
+			if err := updateItem(0, map[string]interface{}{"tags": []string{"t"}}); err != nil {
+				t.Fatal(err)
+			}
+			setLabel(0, "c")
+
+			err := rollbackItem(0, tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			item := dataset[0]
+			if item.Label != tt.wantLabel || !reflect.DeepEqual(item.Tags, tt.wantTags) || item.UserVerified != tt.wantVerify {
+				t.Errorf("item = %+v", item)
+			}
+			if tt.wantErr {
+				return
+			}
+			if item.Version != 4 {
+				t.Errorf("version = %d, want 4", item.Version)
+			}
+			last := auditLog[len(auditLog)-1]
+			if last.Field != "rollback" || last.OldValue != 3 || last.NewValue != tt.version {
+				t.Errorf("last audit entry = %+v", last)
+			}
+		})
+	}
+}
+
+func TestRollbackItemAfterRollback(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Label: "a"})
+	setLabel(0, "b")
+	setLabel(0, "c")
+	if err := rollbackItem(0, 1); err != nil {
+		t.Fatal(err)
+	}
+	// Rolling back across the first rollback replays its label change too
+	if err := rollbackItem(0, 2); err != nil {
+		t.Fatal(err)
+	}
+	if dataset[0].Label != "c" {
+		t.Errorf("label = %q, want c", dataset[0].Label)
+	}
+}
+
+func TestRollbackItemAfterRestore(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Label: "a"})
+	setLabel(0, "b")
+	if err := deleteItem(0); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreDeleted(1); err != nil {
+		t.Fatal(err)
+	}
+	setLabel(0, "c")
+	if err := rollbackItem(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if dataset[0].Label != "a" {
+		t.Errorf("label = %q, want a", dataset[0].Label)
+	}
+}
+
+func TestRollbackItemMissingHistory(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Label: "a", Version: 5})
+	setLabel(0, "b")
+	if err := rollbackItem(0, 2); err == nil {
+		t.Error("rollback before the recorded history succeeded")
+	}
+	if err := rollbackItem(0, 5); err != nil || dataset[0].Label != "a" {
+		t.Errorf("rollback to 5 = %v, label %q", err, dataset[0].Label)
+	}
+}
+
+func TestConvertFieldValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		field string
+		want  interface{}
+	}{
+		{"nil", nil, "Tags", []string(nil)},
+		{"same type", "x", "Label", "x"},
+		{"JSON array", []interface{}{"a", "b"}, "Tags", []string{"a", "b"}},
+		{"JSON object", map[string]interface{}{"a": 0.5}, "ModelPreds", map[string]float64{"a": 0.5}},
+		{"JSON number", float64(2), "Confidence", float64(2)},
+	}
+	itemType := reflect.TypeOf(DataItem{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			field, _ := itemType.FieldByName(tt.field)
+			got, err := convertFieldValue(tt.value, field.Type)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("convertFieldValue = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportAuditLog(t *testing.T) {
+	useDataset(t)
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	auditLog = []ChangeRecord{
+		{ItemID: 2, Version: 1, User: "bob", Field: "tags", OldValue: nil, NewValue: []string{"a", "b"}, Timestamp: base.Add(time.Minute)},
+		{ItemID: 1, Version: 3, User: "alice", Field: "label", OldValue: "x", NewValue: "y, z", Timestamp: base},
+	}
+	var buf bytes.Buffer
+	if err := exportAuditLog(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"item_id", "timestamp", "user", "version", "field", "old_value", "new_value"},
+		{"1", "2024-03-01T12:00:00Z", "alice", "3", "label", "x", "y, z"},
+		{"2", "2024-03-01T12:01:00Z", "bob", "1", "tags", "", `["a","b"]`},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %q, want %q", rows, want)
+	}
+}
+
+func TestFormatHistory(t *testing.T) {
+	stamp := time.Date(2024, 1, 2, 15, 4, 5, 0, time.Local)
+	records := []ChangeRecord{
+		{Field: "label", OldValue: "positive", NewValue: "negative", Timestamp: stamp},
+		{Field: "confidence", OldValue: nil, NewValue: 0.5, Timestamp: stamp},
+		{Field: "deleted", OldValue: DataItem{ID: 7}, Timestamp: stamp},
+	}
+	want := strings.Join([]string{
+		`2024-01-02 15:04:05  label: "positive" -> "negative"`,
+		`2024-01-02 15:04:05  confidence: (none) -> 0.5`,
+		`2024-01-02 15:04:05  deleted: item 7 -> (none)`,
+	}, "\n")
+	if got := formatHistory(records); got != want {
+		t.Errorf("formatHistory =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// IDStrategy decides how nextID numbers new items
+type IDStrategy int
+
+const (
+	IDCounter  IDStrategy = iota // one more than the highest ID ever issued
+	IDTextHash                   // derived from the item's text
+	IDRandom                     // random positive 63-bit number
+)
+
+// idStrategy is the strategy used for items added to the dataset
+var idStrategy = IDCounter
+
+// lastIssuedID is the highest ID handed out so far, so IDs of deleted items
+// are not issued again by IDCounter
+var lastIssuedID int
+
+// nextID returns an ID for a new item with the given text that is not in used,
+// and marks it used
+// Callers hold datasetMu and seed used with usedIDs
+func nextID(used map[int]bool, text string) int {
+	var id int
+	switch idStrategy {
+	case IDTextHash:
+		hash := fnv.New64a()
+		hash.Write([]byte(text))
+		id = int(hash.Sum64() >> 1)
+	case IDRandom:
+		var buf [8]byte
+		rand.Read(buf[:])
+		id = int(binary.BigEndian.Uint64(buf[:]) >> 1)
+	default:
+		id = lastIssuedID + 1
+	}
+	// Taken IDs, such as the hash of a repeated text, probe upwards
+	for id <= 0 || used[id] {
+		id++
+	}
+
+	used[id] = true
+	if idStrategy == IDCounter {
+		lastIssuedID = id
+	}
+	return id
+}
+
+// usedIDs collects the IDs of the current dataset and the trash, so a
+// restored item never clashes with a new one
+// Under IDCounter it also moves lastIssuedID past IDs that arrived from
+// elsewhere, such as a merge, so they are not reused once deleted
+func usedIDs() map[int]bool {
+	used := make(map[int]bool, len(dataset)+len(trash))
+	for _, items := range [][]DataItem{dataset, trash} {
+		for _, item := range items {
+			used[item.ID] = true
+			if idStrategy == IDCounter && item.ID > lastIssuedID {
+				lastIssuedID = item.ID
+			}
+		}
+	}
+	return used
+}
+
+// retireID keeps IDCounter from issuing id again once its item is removed
+// Callers hold datasetMu
+func retireID(id int) {
+	if idStrategy == IDCounter && id > lastIssuedID {
+		lastIssuedID = id
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestNextID(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy IDStrategy
+	}{
+		{"counter", IDCounter},
+		{"text hash", IDTextHash},
+		{"random", IDRandom},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t)
+			idStrategy = tt.strategy
+			defer func() { idStrategy = IDCounter }()
+
+			used := map[int]bool{}
+			first := nextID(used, "same")
+			second := nextID(used, "same")
+			if first <= 0 || second <= 0 || first == second {
+				t.Errorf("IDs %d, %d, want distinct positive IDs", first, second)
+			}
+			if !used[first] || !used[second] {
+				t.Error("issued IDs not marked used")
+			}
+		})
+	}
+}
+
+func TestNextIDTextHashIsStable(t *testing.T) {
+	useDataset(t)
+	idStrategy = IDTextHash
+	defer func() { idStrategy = IDCounter }()
+
+	a := nextID(map[int]bool{}, "hello")
+	b := nextID(map[int]bool{}, "hello")
+	if a != b {
+		t.Errorf("hash IDs %d and %d differ for the same text", a, b)
+	}
+	if c := nextID(map[int]bool{}, "world"); c == a {
+		t.Errorf("different texts share ID %d", c)
+	}
+}
+
+func TestNoIDReuseAfterDelete(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func() error
+	}{
+		{"trashed then purged", func() error {
+			err := deleteItem(2)
+			emptyTrash()
+			return err
+		}},
+		{"hard delete", func() error {
+			softDelete = false
+			defer func() { softDelete = true }()
+			return deleteItem(2)
+		}},
+		{"deduplicate", func() error {
+			dataset[2].Text = "one"
+			deduplicate()
+			return nil
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			if err := tt.remove(); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := importCSV(strings.NewReader("text\nnew\n")); err != nil {
+				t.Fatal(err)
+			}
+			if got := ids(); !reflect.DeepEqual(got, []int{1, 2, 4}) {
+				t.Errorf("IDs = %v, want [1 2 4] so deleted ID 3 is not reused", got)
+			}
+		})
+	}
+}
+
+func TestUsedIDsCoversTrash(t *testing.T) {
+	useDataset(t, DataItem{ID: 2})
+	trash = []DataItem{{ID: 9}}
+
+	datasetMu.Lock()
+	used := usedIDs()
+	id := nextID(used, "")
+	datasetMu.Unlock()
+	if !used[2] || !used[9] {
+		t.Errorf("usedIDs = %v, want 2 and 9", used)
+	}
+	if id != 10 || lastIssuedID != 10 {
+		t.Errorf("nextID = %d with lastIssuedID %d, want 10", id, lastIssuedID)
+	}
+}
@@ -0,0 +1,749 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// jsonlRecord is the shape of a single line in a JSONL import file
+type jsonlRecord struct {
+	Text     string   `json:"text"`
+	Label    string   `json:"label"`
+	Category string   `json:"category"`
+	Tags     []string `json:"tags"`
+}
+
+// importJSONL appends one item per JSON line, skipping blank lines
+// Nothing is added if any line fails to parse, or fails validation when
+// strictImport is set
+func importJSONL(reader io.Reader) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+
+	var items []DataItem
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record jsonlRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return fmt.Errorf("line %d: %w", lineNum, err)
+		}
+
+		item := DataItem{
+			Text:        record.Text,
+			Category:    record.Category,
+			Tags:        record.Tags,
+			Label:       record.Label,
+			ModelPreds:  make(map[string]float64),
+			LastUpdated: time.Now(),
+		}
+		normalizeImportedText(&item, record.Text)
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("line %d: %w", lineNum+1, err)
+	}
+	if strictImport {
+		if problems := validateItems(items); len(problems) > 0 {
+			return importValidationError(problems)
+		}
+	}
+
+	appendItems(items)
+	return nil
+}
+
+// importJSON reads a document written by exportJSON and either replaces
+// the dataset with its items, clearing the trash and audit log, or appends
+// them
+// Items keep their IDs and versions, except appended items whose ID is
+// taken, which get a new one
+// Unknown fields are ignored
+func importJSON(reader io.Reader, replace bool) error {
+	var envelope struct {
+		Metadata *MetricsData `json:"metadata"`
+		Data     *[]DataItem  `json:"data"`
+	}
+	if err := json.NewDecoder(reader).Decode(&envelope); err != nil {
+		return fmt.Errorf("parsing JSON export: %w", err)
+	}
+	if envelope.Metadata == nil || envelope.Data == nil {
+		return fmt.Errorf("JSON export is missing its metadata or data section")
+	}
+	items := *envelope.Data
+	if strictImport {
+		if problems := validateItems(items); len(problems) > 0 {
+			return importValidationError(problems)
+		}
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	entry := importUndo{added: make(map[int]bool, len(items))}
+	if replace {
+		dataset = items
+		undoStack, redoStack, importStack = nil, nil, nil
+		// The trash and the audit log belonged to the dataset being replaced
+		trash = nil
+		auditLog = nil
+	} else {
+		used := usedIDs()
+		for i := range items {
+			if items[i].ID <= 0 || used[items[i].ID] {
+				items[i].ID = nextID(used, items[i].Text)
+			}
+			used[items[i].ID] = true
+			entry.added[items[i].ID] = true
+		}
+		dataset = append(dataset, items...)
+	}
+	// Keeps the ID counter past IDs taken from the file
+	usedIDs()
+	if !replace {
+		pushImport(entry)
+	}
+	datasetChanged()
+	return nil
+}
+
+// appendItems adds items to the end of the dataset, giving each a new ID
+// from nextID
+func appendItems(items []DataItem) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	entry := importUndo{added: make(map[int]bool, len(items))}
+	used := usedIDs()
+	for i := range items {
+		items[i].ID = nextID(used, items[i].Text)
+		entry.added[items[i].ID] = true
+	}
+	dataset = append(dataset, items...)
+	pushImport(entry)
+	datasetChanged()
+}
+
+// csvDelimiter is the field separator used by importCSV
+// When zero, the separator is detected from the header line
+var csvDelimiter rune
+
+// importProgress, when set, is called during CSV imports after every
+// importProgressEvery rows with the number of rows read so far
+var importProgress func(processed int)
+var importProgressEvery = 1000
+
+// sanitizeText makes CSV imports replace invalid UTF-8 and strip control
+// characters other than tabs and newlines
+var sanitizeText bool
+
+// NormalizeOptions select how importers clean up item text
+// KeepRaw stores the text as read in RawText before it is normalized
+type NormalizeOptions struct {
+	TrimSpace          bool
+	LowerCase          bool
+	CollapseWhitespace bool
+	KeepRaw            bool
+}
+
+// importNormalize is applied to the text of every imported item
+// The zero value leaves text as read
+var importNormalize NormalizeOptions
+
+// normalizeText applies options to text; collapsing turns every run of
+// whitespace into a single space
+func normalizeText(text string, options NormalizeOptions) string {
+	if options.CollapseWhitespace {
+		var collapsed strings.Builder
+		inSpace := false
+		for _, r := range text {
+			if unicode.IsSpace(r) {
+				if !inSpace {
+					collapsed.WriteByte(' ')
+				}
+				inSpace = true
+				continue
+			}
+			inSpace = false
+			collapsed.WriteRune(r)
+		}
+		text = collapsed.String()
+	}
+	if options.TrimSpace {
+		text = strings.TrimSpace(text)
+	}
+	if options.LowerCase {
+		text = strings.ToLower(text)
+	}
+	return text
+}
+
+// normalizeImportedText applies importNormalize to item.Text, given the
+// text as it was read
+func normalizeImportedText(item *DataItem, raw string) {
+	item.Text = normalizeText(item.Text, importNormalize)
+	if importNormalize.KeepRaw {
+		item.RawText = raw
+	}
+}
+
+// importCSV appends one item per row, using the header row to locate
+// the text, category, label, tags, confidence and pred_<label> columns,
+// and returns how many rows sanitizeText had to change
+// Nothing is added if any row fails to parse, or fails validation when
+// strictImport is set
+func importCSV(reader io.Reader) (sanitized int, err error) {
+	return importDelimited(reader, csvDelimiter)
+}
+
+// importCSVUpsert imports rows like importCSV, except that a row whose id
+// column matches an existing item updates that item's imported columns
+// through the audit log instead of adding a copy
+// Other rows are appended, keeping their id when it is free, and the
+// numbers of updated and added items are returned
+// Nothing is changed if any row fails to parse or would update an item
+// locked by another user
+func importCSVUpsert(reader io.Reader) (updated, added int, err error) {
+	csvReader, rawHeaders, err := openDelimited(reader, csvDelimiter)
+	if err != nil || rawHeaders == nil {
+		return 0, 0, err
+	}
+	headers := make([]string, len(rawHeaders))
+	idColumn := -1
+	var keys []string
+	for i, h := range rawHeaders {
+		headers[i] = normalizeHeader(h)
+		switch column := headers[i]; {
+		case column == "id":
+			idColumn = i
+		case column == "text", column == "category", column == "label", column == "tags", column == "confidence":
+			keys = append(keys, column)
+		case strings.HasPrefix(column, "pred_") && !containsString(keys, "model_preds"):
+			keys = append(keys, "model_preds")
+		}
+	}
+
+	var items []DataItem
+	var ids []int
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("row %d: %w", row, err)
+		}
+		if sanitizeText {
+			sanitizeRecord(record)
+		}
+		item, err := itemFromRecord(headers, record, 0)
+		if err != nil {
+			return 0, 0, fmt.Errorf("row %d: %w", row, err)
+		}
+		id := 0
+		if idColumn >= 0 && idColumn < len(record) && strings.TrimSpace(record[idColumn]) != "" {
+			value := strings.TrimSpace(record[idColumn])
+			if id, err = strconv.Atoi(value); err != nil || id <= 0 {
+				return 0, 0, fmt.Errorf("row %d: invalid id %q", row, value)
+			}
+		}
+		items = append(items, item)
+		ids = append(ids, id)
+	}
+	if strictImport {
+		if problems := validateItems(items); len(problems) > 0 {
+			return 0, 0, importValidationError(problems)
+		}
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	positions := make(map[int]int, len(dataset))
+	for i, item := range dataset {
+		positions[item.ID] = i
+	}
+	entry := importUndo{
+		added:   make(map[int]bool),
+		updated: make(map[int]map[string]interface{}),
+	}
+
+	// Every update is checked before any is written, so a locked item
+	// leaves the whole file unimported
+	type upsert struct {
+		index   int
+		updates map[string]interface{}
+	}
+	var upserts []upsert
+	var appending []DataItem
+	for i, item := range items {
+		index, ok := positions[ids[i]]
+		if !ok {
+			item.ID = ids[i]
+			appending = append(appending, item)
+			continue
+		}
+		current := reflect.ValueOf(dataset[index])
+		incoming := reflect.ValueOf(item)
+		updates := make(map[string]interface{})
+		prior := make(map[string]interface{})
+		for _, key := range keys {
+			value := incoming.FieldByName(updateFields[key]).Interface()
+			old := current.FieldByName(updateFields[key]).Interface()
+			if !reflect.DeepEqual(old, value) {
+				updates[key] = value
+				prior[key] = old
+			}
+		}
+		if len(updates) == 0 {
+			continue
+		}
+		if err := checkUnlocked(index, currentUser); err != nil {
+			return 0, 0, fmt.Errorf("item %d: %w", ids[i], err)
+		}
+		if err := checkUpdates(updates); err != nil {
+			return 0, 0, fmt.Errorf("item %d: %w", ids[i], err)
+		}
+		upserts = append(upserts, upsert{index, updates})
+		// A repeated id keeps the values from before its first row
+		if earlier := entry.updated[ids[i]]; earlier != nil {
+			for key, value := range earlier {
+				prior[key] = value
+			}
+		}
+		entry.updated[ids[i]] = prior
+	}
+
+	now := time.Now()
+	var changes []undoEntry
+	for _, u := range upserts {
+		changes = append(changes, writeUpdates(u.index, u.updates, now))
+	}
+	if len(changes) > 0 {
+		pushUndo(undoEntry{batch: changes})
+	}
+
+	used := usedIDs()
+	for i := range appending {
+		if id := appending[i].ID; id > 0 && !used[id] {
+			used[id] = true
+		} else {
+			appending[i].ID = nextID(used, appending[i].Text)
+		}
+		entry.added[appending[i].ID] = true
+	}
+	if len(appending) > 0 {
+		dataset = append(dataset, appending...)
+		// Keeps the ID counter past ids taken from the file
+		usedIDs()
+	}
+
+	if len(changes) > 0 || len(appending) > 0 {
+		pushImport(entry)
+		datasetChanged()
+	}
+	return len(changes), len(appending), nil
+}
+
+// importTSV is importCSV for tab-separated files
+func importTSV(reader io.Reader) (sanitized int, err error) {
+	return importDelimited(reader, '\t')
+}
+
+func importDelimited(reader io.Reader, delimiter rune) (int, error) {
+	var items []DataItem
+	sanitized, err := streamDelimited(reader, delimiter, func(item DataItem) error {
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return sanitized, err
+	}
+	if strictImport {
+		if problems := validateItems(items); len(problems) > 0 {
+			return sanitized, importValidationError(problems)
+		}
+	}
+
+	appendItems(items)
+	return sanitized, nil
+}
+
+// importCSVStream parses rows one at a time and passes each item to handler
+// without adding it to the dataset, so large files need not fit in memory
+// Each item gets its ID from nextID, so it never clashes with the dataset,
+// the trash or an earlier stream
+func importCSVStream(reader io.Reader, handler func(DataItem) error) (sanitized int, err error) {
+	datasetMu.Lock()
+	used := usedIDs()
+	datasetMu.Unlock()
+
+	return streamDelimited(reader, csvDelimiter, func(item DataItem) error {
+		datasetMu.Lock()
+		item.ID = nextID(used, item.Text)
+		datasetMu.Unlock()
+		return handler(item)
+	})
+}
+
+// streamDelimited parses rows separated by delimiter, detecting it from
+// the header line when it is zero, and returns how many rows sanitizeText
+// changed
+// Items reach handler without an ID
+func streamDelimited(reader io.Reader, delimiter rune, handler func(DataItem) error) (sanitized int, err error) {
+	csvReader, rawHeaders, err := openDelimited(reader, delimiter)
+	if err != nil || rawHeaders == nil {
+		return 0, err
+	}
+	headers := make([]string, len(rawHeaders))
+	for i, h := range rawHeaders {
+		headers[i] = normalizeHeader(h)
+	}
+
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return sanitized, fmt.Errorf("row %d: %w", row, err)
+		}
+
+		if sanitizeText && sanitizeRecord(record) {
+			sanitized++
+		}
+		item, err := itemFromRecord(headers, record, 0)
+		if err != nil {
+			return sanitized, fmt.Errorf("row %d: %w", row, err)
+		}
+		if err := handler(item); err != nil {
+			return sanitized, fmt.Errorf("row %d: %w", row, err)
+		}
+		if importProgress != nil && row%importProgressEvery == 0 {
+			importProgress(row)
+		}
+	}
+	return sanitized, nil
+}
+
+// openDelimited reads the header row, detecting the delimiter from it when
+// delimiter is zero, and returns a reader positioned at the first data row
+// The headers are nil for empty input
+func openDelimited(reader io.Reader, delimiter rune) (*csv.Reader, []string, error) {
+	buffered := bufio.NewReader(reader)
+	if delimiter == 0 {
+		header, err := buffered.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		delimiter = detectDelimiter(header)
+		reader = io.MultiReader(strings.NewReader(header), buffered)
+	} else {
+		reader = buffered
+	}
+
+	csvReader := csv.NewReader(reader)
+	csvReader.Comma = delimiter
+	csvReader.ReuseRecord = true
+
+	headerRow, err := csvReader.Read()
+	if err == io.EOF {
+		return csvReader, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading header: %w", err)
+	}
+	// Copied because the reader reuses its record slice
+	return csvReader, append([]string(nil), headerRow...), nil
+}
+
+// previewCSV parses up to n rows the way importCSV would, without adding
+// them to the dataset, so the column mapping can be checked first
+// mapping gives the column each header is read as, or "" when it is ignored,
+// and preview items are numbered by row
+func previewCSV(reader io.Reader, n int) (headers []string, rows []DataItem, mapping map[string]string, err error) {
+	csvReader, headers, err := openDelimited(reader, csvDelimiter)
+	if err != nil || headers == nil {
+		return nil, nil, nil, err
+	}
+
+	normalized := make([]string, len(headers))
+	mapping = make(map[string]string, len(headers))
+	for i, h := range headers {
+		normalized[i] = normalizeHeader(h)
+		switch column := normalized[i]; {
+		case column == "text", column == "category", column == "label", column == "tags",
+			column == "confidence", strings.HasPrefix(column, "pred_"):
+			mapping[h] = column
+		default:
+			mapping[h] = ""
+		}
+	}
+
+	for row := 1; row <= n; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return headers, rows, mapping, fmt.Errorf("row %d: %w", row, err)
+		}
+		if sanitizeText {
+			sanitizeRecord(record)
+		}
+		item, err := itemFromRecord(normalized, record, row)
+		if err != nil {
+			return headers, rows, mapping, fmt.Errorf("row %d: %w", row, err)
+		}
+		rows = append(rows, item)
+	}
+	return headers, rows, mapping, nil
+}
+
+// sanitizeRecord cleans each cell in place and reports whether any changed
+func sanitizeRecord(record []string) bool {
+	changed := false
+	for i, cell := range record {
+		clean := strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+				return -1
+			}
+			return r
+		}, strings.ToValidUTF8(cell, "\uFFFD"))
+		if clean != cell {
+			record[i] = clean
+			changed = true
+		}
+	}
+	return changed
+}
+
+// headerAliases maps alternative column names, such as "content" or "class",
+// to the canonical names importers understand; keys ignore case
+var headerAliases map[string]string
+
+// normalizeHeader lowercases a column name and resolves any alias
+func normalizeHeader(header string) string {
+	header = strings.TrimSpace(header)
+	for alias, canonical := range headerAliases {
+		if strings.EqualFold(alias, header) {
+			return strings.ToLower(canonical)
+		}
+	}
+	return strings.ToLower(header)
+}
+
+// itemFromRecord builds an item from one row of cells, matched to lowercase headers
+// Rows shorter than the header are treated as having empty trailing cells
+func itemFromRecord(headers, record []string, id int) (DataItem, error) {
+	item := DataItem{
+		ID:          id,
+		ModelPreds:  make(map[string]float64),
+		LastUpdated: time.Now(),
+	}
+	for i, header := range headers {
+		raw, value := "", ""
+		if i < len(record) {
+			raw = record[i]
+			value = strings.TrimSpace(raw)
+		}
+		switch header {
+		case "text":
+			item.Text = value
+			normalizeImportedText(&item, raw)
+		case "category":
+			item.Category = value
+		case "label":
+			item.Label = value
+		case "tags":
+			item.Tags = splitTags(value)
+		case "confidence":
+			if value == "" {
+				continue
+			}
+			confidence, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return item, fmt.Errorf("invalid confidence %q", value)
+			}
+			item.Confidence = confidence
+		default:
+			if !strings.HasPrefix(header, "pred_") || value == "" {
+				continue
+			}
+			score, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return item, fmt.Errorf("invalid %s %q", header, value)
+			}
+			item.ModelPreds[strings.TrimPrefix(header, "pred_")] = score
+		}
+	}
+	return item, nil
+}
+
+// dropEmptyTags makes splitTags trim tags and discard empty ones, such as
+// those left by doubled or trailing commas
+var dropEmptyTags = true
+
+// splitTags reads a comma-separated tags cell; with dropEmptyTags a blank
+// cell gives an empty slice
+func splitTags(value string) []string {
+	tags := strings.Split(value, ",")
+	if !dropEmptyTags {
+		return tags
+	}
+	kept := tags[:0]
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			kept = append(kept, tag)
+		}
+	}
+	return kept
+}
+
+// importExcel appends one item per row of the workbook's first sheet,
+// reading the same columns as importCSV from its header row
+// Blank rows are skipped and nothing is added if any row fails to parse,
+// or fails validation when strictImport is set
+func importExcel(path string) error {
+	workbook, err := excelize.OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer workbook.Close()
+
+	sheets := workbook.GetSheetList()
+	if len(sheets) == 0 {
+		return fmt.Errorf("%s has no sheets", path)
+	}
+	rows, err := workbook.GetRows(sheets[0])
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+
+	headers := make([]string, len(rows[0]))
+	for i, h := range rows[0] {
+		headers[i] = normalizeHeader(h)
+	}
+
+	var items []DataItem
+	for row, record := range rows[1:] {
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		item, err := itemFromRecord(headers, record, 0)
+		if err != nil {
+			return fmt.Errorf("row %d: %w", row+1, err)
+		}
+		items = append(items, item)
+	}
+	if strictImport {
+		if problems := validateItems(items); len(problems) > 0 {
+			return importValidationError(problems)
+		}
+	}
+
+	appendItems(items)
+	return nil
+}
+
+// importTextDir appends one item per file found under root, taking the text
+// from the file, the label from its parent directory and the category from
+// the top-level directory below root
+// Binary and non-UTF-8 files are skipped and their count returned
+func importTextDir(root string) (int, error) {
+	var items []DataItem
+	skipped := 0
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if !utf8.Valid(content) || strings.ContainsRune(string(content), 0) {
+			skipped++
+			return nil
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		dirs := strings.Split(filepath.Dir(rel), string(filepath.Separator))
+		item := DataItem{
+			Text:        string(content),
+			ModelPreds:  make(map[string]float64),
+			LastUpdated: time.Now(),
+		}
+		if dirs[0] != "." {
+			item.Category = dirs[0]
+			item.Label = dirs[len(dirs)-1]
+		}
+		normalizeImportedText(&item, item.Text)
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return skipped, err
+	}
+	if strictImport {
+		if problems := validateItems(items); len(problems) > 0 {
+			return skipped, importValidationError(problems)
+		}
+	}
+
+	appendItems(items)
+	return skipped, nil
+}
+
+// detectDelimiter picks the candidate separator that occurs most often
+// outside quotes in the header line, falling back to a comma
+func detectDelimiter(header string) rune {
+	counts := make(map[rune]int)
+	inQuotes := false
+	for _, r := range header {
+		switch r {
+		case '"':
+			inQuotes = !inQuotes
+		case ',', ';', '\t', '|':
+			if !inQuotes {
+				counts[r]++
+			}
+		}
+	}
+
+	best := ','
+	for _, candidate := range []rune{',', ';', '\t', '|'} {
+		if counts[candidate] > counts[best] {
+			best = candidate
+		}
+	}
+	return best
+}
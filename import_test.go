@@ -0,0 +1,572 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestImportCSVSanitize(t *testing.T) {
+	tests := []struct {
+		name          string
+		sanitize      bool
+		input         string
+		wantSanitized int
+		wantText      []string
+	}{
+		{
+			name:          "invalid bytes replaced",
+			sanitize:      true,
+			input:         "text\nbad \xff byte\nclean\n",
+			wantSanitized: 1,
+			wantText:      []string{"bad � byte", "clean"},
+		},
+		{
+			name:          "null bytes stripped",
+			sanitize:      true,
+			input:         "text\nnull\x00byte\nbell\x07\n",
+			wantSanitized: 2,
+			wantText:      []string{"nullbyte", "bell"},
+		},
+		{
+			name:     "raw by default",
+			input:    "text\nnull\x00byte\n",
+			wantText: []string{"null\x00byte"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t)
+			sanitizeText = tt.sanitize
+			defer func() { sanitizeText = false }()
+
+			sanitized, err := importCSV(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if sanitized != tt.wantSanitized {
+				t.Errorf("sanitized = %d, want %d", sanitized, tt.wantSanitized)
+			}
+			for i, want := range tt.wantText {
+				if dataset[i].Text != want {
+					t.Errorf("item %d text = %q, want %q", i, dataset[i].Text, want)
+				}
+			}
+		})
+	}
+}
+
+// TestImportCSVSanitizeConcurrent is meant for go test -race
+func TestImportCSVSanitizeConcurrent(t *testing.T) {
+	useDataset(t)
+	sanitizeText = true
+	defer func() { sanitizeText = false }()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sanitized, err := importCSV(strings.NewReader("text\na\x00\nb\n"))
+			if err != nil || sanitized != 1 {
+				t.Errorf("importCSV = %d, %v, want 1, nil", sanitized, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestImportCSVUpsert(t *testing.T) {
+	existing := func() []DataItem {
+		return []DataItem{
+			{ID: 1, Text: "one", Label: "a", ModelPreds: map[string]float64{}},
+			{ID: 2, Text: "two", Label: "a", ModelPreds: map[string]float64{}},
+		}
+	}
+	tests := []struct {
+		name        string
+		input       string
+		lockSecond  bool
+		wantUpdated int
+		wantAdded   int
+		wantErr     bool
+		wantLabels  []string
+		wantIDs     []int
+	}{
+		{
+			name:        "update and append in one file",
+			input:       "id,text,label\n1,one,b\n,three,c\n7,seven,d\n",
+			wantUpdated: 1,
+			wantAdded:   2,
+			wantLabels:  []string{"b", "a", "c", "d"},
+			wantIDs:     []int{1, 2, 3, 7},
+		},
+		{
+			name:       "unchanged row is not an update",
+			input:      "id,text,label\n2,two,a\n",
+			wantLabels: []string{"a", "a"},
+			wantIDs:    []int{1, 2},
+		},
+		{
+			name:       "locked row changes nothing",
+			input:      "id,text,label\n1,one,b\n2,two,b\n,three,c\n",
+			lockSecond: true,
+			wantErr:    true,
+			wantLabels: []string{"a", "a"},
+			wantIDs:    []int{1, 2},
+		},
+		{
+			name:       "bad row changes nothing",
+			input:      "id,text,label\n1,one,b\nx,two,b\n",
+			wantErr:    true,
+			wantLabels: []string{"a", "a"},
+			wantIDs:    []int{1, 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, existing()...)
+			if tt.lockSecond {
+				if err := lockItem(1, "alice"); err != nil {
+					t.Fatal(err)
+				}
+				currentUser = "bob"
+			}
+
+			updated, added, err := importCSVUpsert(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if updated != tt.wantUpdated || added != tt.wantAdded {
+				t.Errorf("updated, added = %d, %d, want %d, %d", updated, added, tt.wantUpdated, tt.wantAdded)
+			}
+			if got := labelsOf(); !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", got, tt.wantLabels)
+			}
+			var ids []int
+			for _, item := range dataset {
+				ids = append(ids, item.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if tt.wantErr && (len(importStack) > 0 || len(undoStack) > 0 || len(auditLog) > 0) {
+				t.Errorf("failed import left history: %d imports, %d undo, %d audit",
+					len(importStack), len(undoStack), len(auditLog))
+			}
+		})
+	}
+}
+
+func TestImportCSVUpsertUndo(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "one", Label: "a", ModelPreds: map[string]float64{}})
+	if _, _, err := importCSVUpsert(strings.NewReader("id,text,label\n1,one,b\n1,one,c\n,two,d\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := labelsOf(); !reflect.DeepEqual(got, []string{"c", "d"}) {
+		t.Fatalf("labels after import = %v", got)
+	}
+	if err := undoImport(); err != nil {
+		t.Fatal(err)
+	}
+	if got := labelsOf(); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("labels after undo = %v, want [a]", got)
+	}
+}
+
+func TestImportJSONL(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantErr   string
+		wantTexts []string
+	}{
+		{
+			name:      "skips blank lines",
+			input:     "{\"text\": \"a\", \"label\": \"x\", \"tags\": [\"t\"]}\n\n  \n{\"text\": \"b\"}\n",
+			wantTexts: []string{"a", "b"},
+		},
+		{
+			name:    "malformed line adds nothing",
+			input:   "{\"text\": \"a\"}\n{\"text\": \n",
+			wantErr: "line 2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t)
+			err := importJSONL(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one mentioning %q", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			var texts []string
+			for _, item := range dataset {
+				texts = append(texts, item.Text)
+			}
+			if !reflect.DeepEqual(texts, tt.wantTexts) {
+				t.Errorf("texts = %v, want %v", texts, tt.wantTexts)
+			}
+		})
+	}
+}
+
+func TestImportCSVColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		delimiter rune
+		aliases   map[string]string
+		input     string
+		want      DataItem
+		wantErr   bool
+	}{
+		{
+			name:  "comma",
+			input: "text,category,label,tags,confidence,pred_pos\nhello,c,pos,\"a, b\",0.5,0.9\n",
+			want: DataItem{Text: "hello", Category: "c", Label: "pos", Tags: []string{"a", "b"},
+				Confidence: 0.5, ModelPreds: map[string]float64{"pos": 0.9}},
+		},
+		{
+			name:  "detected semicolon",
+			input: "text;label\nhello, world;pos\n",
+			want:  DataItem{Text: "hello, world", Label: "pos", ModelPreds: map[string]float64{}},
+		},
+		{
+			name:  "detected pipe",
+			input: "text|label\nhello|pos\n",
+			want:  DataItem{Text: "hello", Label: "pos", ModelPreds: map[string]float64{}},
+		},
+		{
+			name:      "configured delimiter",
+			delimiter: ';',
+			input:     "text;label\nhello|x;pos\n",
+			want:      DataItem{Text: "hello|x", Label: "pos", ModelPreds: map[string]float64{}},
+		},
+		{
+			name:    "aliases",
+			aliases: map[string]string{"Content": "text", "class": "Label"},
+			input:   "CONTENT,Class\nhello,pos\n",
+			want:    DataItem{Text: "hello", Label: "pos", ModelPreds: map[string]float64{}},
+		},
+		{
+			name:    "bad confidence",
+			input:   "text,confidence\nhello,high\n",
+			wantErr: true,
+		},
+		{
+			name:    "bad prediction",
+			input:   "text,pred_pos\nhello,high\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t)
+			csvDelimiter, headerAliases = tt.delimiter, tt.aliases
+			defer func() { csvDelimiter, headerAliases = 0, nil }()
+
+			_, err := importCSV(strings.NewReader(tt.input))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(dataset) != 0 {
+					t.Errorf("failed import added %d items", len(dataset))
+				}
+				return
+			}
+			if len(dataset) != 1 {
+				t.Fatalf("got %d items, want 1", len(dataset))
+			}
+			got := dataset[0]
+			if got.Text != tt.want.Text || got.Category != tt.want.Category || got.Label != tt.want.Label ||
+				got.Confidence != tt.want.Confidence || !reflect.DeepEqual(got.ModelPreds, tt.want.ModelPreds) ||
+				len(got.Tags) != len(tt.want.Tags) || len(got.Tags) > 0 && !reflect.DeepEqual(got.Tags, tt.want.Tags) {
+				t.Errorf("item = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportCSVStream(t *testing.T) {
+	useDataset(t, DataItem{ID: 1}, DataItem{ID: 4})
+	trash = []DataItem{{ID: 6}}
+	var streamed []DataItem
+	_, err := importCSVStream(strings.NewReader("text\na\nb\n"), func(item DataItem) error {
+		streamed = append(streamed, item)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dataset) != 2 {
+		t.Errorf("stream added %d items to the dataset", len(dataset)-2)
+	}
+	// IDs skip past both the dataset and the trash
+	if len(streamed) != 2 || streamed[0].ID != 7 || streamed[1].ID != 8 || streamed[1].Text != "b" {
+		t.Errorf("streamed = %+v", streamed)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	_, err = importCSVStream(strings.NewReader("text\na\nb\n"), func(DataItem) error {
+		calls++
+		return stop
+	})
+	if !errors.Is(err, stop) || calls != 1 {
+		t.Errorf("handler error: err = %v after %d calls", err, calls)
+	}
+}
+
+func BenchmarkImportCSVStream(b *testing.B) {
+	var input strings.Builder
+	input.WriteString("text,label,confidence\n")
+	for i := 0; i < 100000; i++ {
+		fmt.Fprintf(&input, "example text number %d,label%d,0.%d\n", i, i%5, i%10)
+	}
+	data := input.String()
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(data)))
+	for i := 0; i < b.N; i++ {
+		datasetMu.Lock()
+		dataset, trash, lastIssuedID = nil, nil, 0
+		datasetMu.Unlock()
+		_, err := importCSVStream(strings.NewReader(data), func(DataItem) error { return nil })
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestImportExcel(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "existing"})
+	headerAliases = map[string]string{"content": "text"}
+	defer func() { headerAliases = nil }()
+
+	// The fixture's headers are "Content", "Category", " LABEL ", "Tags",
+	// "Confidence" and two pred_ columns, with a blank row between the items
+	// and numeric cells in the last row
+	if err := importExcel(filepath.Join("testdata", "items.xlsx")); err != nil {
+		t.Fatal(err)
+	}
+	want := []DataItem{
+		{ID: 2, Text: "Great product", Category: "review", Label: "positive", Tags: []string{"a", "b"},
+			Confidence: 0.75, ModelPreds: map[string]float64{"positive": 0.9, "negative": 0.1}},
+		{ID: 3, Text: "12345", Label: "negative", Tags: []string{},
+			Confidence: 1, ModelPreds: map[string]float64{"negative": 0.8}},
+	}
+	if len(dataset) != 3 {
+		t.Fatalf("dataset has %d items, want 3", len(dataset))
+	}
+	for i, item := range dataset[1:] {
+		item.LastUpdated = time.Time{}
+		if !reflect.DeepEqual(item, want[i]) {
+			t.Errorf("item %d = %+v, want %+v", i+1, item, want[i])
+		}
+	}
+
+	if err := importExcel(filepath.Join("testdata", "missing.xlsx")); err == nil {
+		t.Error("importing a missing workbook should fail")
+	}
+}
+
+func TestImportProgress(t *testing.T) {
+	tests := []struct {
+		rows  int
+		every int
+		want  []int
+	}{
+		{rows: 5, every: 2, want: []int{2, 4}},
+		{rows: 1, every: 2, want: nil},
+		{rows: 3, every: 1, want: []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d rows every %d", tt.rows, tt.every), func(t *testing.T) {
+			useDataset(t)
+			var got []int
+			importProgress = func(processed int) { got = append(got, processed) }
+			importProgressEvery = tt.every
+			defer func() { importProgress, importProgressEvery = nil, 1000 }()
+
+			input := "text\n" + strings.Repeat("row\n", tt.rows)
+			if _, err := importCSV(strings.NewReader(input)); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("progress = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeText(t *testing.T) {
+	tests := []struct {
+		name    string
+		options NormalizeOptions
+		input   string
+		want    string
+	}{
+		{"zero value", NormalizeOptions{}, "  Mixed\t Case \n", "  Mixed\t Case \n"},
+		{"trim", NormalizeOptions{TrimSpace: true}, "  Mixed  Case \n", "Mixed  Case"},
+		{"lower", NormalizeOptions{LowerCase: true}, "MiXeD", "mixed"},
+		{"collapse", NormalizeOptions{CollapseWhitespace: true}, "a \t\n b", "a b"},
+		{"all", NormalizeOptions{TrimSpace: true, LowerCase: true, CollapseWhitespace: true}, "\t A  B \n", "a b"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeText(tt.input, tt.options); got != tt.want {
+				t.Errorf("normalizeText(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestImportNormalizeKeepRaw(t *testing.T) {
+	useDataset(t)
+	importNormalize = NormalizeOptions{LowerCase: true, KeepRaw: true}
+	defer func() { importNormalize = NormalizeOptions{} }()
+
+	if err := importJSONL(strings.NewReader(`{"text": "Hello"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if dataset[0].Text != "hello" || dataset[0].RawText != "Hello" {
+		t.Errorf("text, raw = %q, %q", dataset[0].Text, dataset[0].RawText)
+	}
+}
+
+func TestPreviewCSV(t *testing.T) {
+	useDataset(t)
+	headers, rows, mapping, err := previewCSV(strings.NewReader("Text,label,notes\na,x,n1\nb,y,n2\nc,z,n3\n"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dataset) != 0 {
+		t.Errorf("preview added %d items", len(dataset))
+	}
+	if !reflect.DeepEqual(headers, []string{"Text", "label", "notes"}) {
+		t.Errorf("headers = %v", headers)
+	}
+	if want := map[string]string{"Text": "text", "label": "label", "notes": ""}; !reflect.DeepEqual(mapping, want) {
+		t.Errorf("mapping = %v, want %v", mapping, want)
+	}
+	if len(rows) != 2 || rows[0].ID != 1 || rows[1].Text != "b" || rows[1].Label != "y" {
+		t.Errorf("rows = %+v", rows)
+	}
+}
+
+func TestImportJSONRoundTrip(t *testing.T) {
+	original := []DataItem{
+		{ID: 4, Text: "four", Label: "a", Tags: []string{"t"}, Version: 3, ModelPreds: map[string]float64{"a": 1}},
+		{ID: 9, Text: "nine", Notes: "check", ModelPreds: map[string]float64{}},
+	}
+	useDataset(t, original...)
+	var buf bytes.Buffer
+	if err := exportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	exported := buf.String()
+
+	t.Run("replace", func(t *testing.T) {
+		useDataset(t, DataItem{ID: 1, Text: "old"}, DataItem{ID: 4, Text: "also old"})
+		setLabel(1, "stale")
+		if err := importJSON(strings.NewReader(exported), true); err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(); !reflect.DeepEqual(got, []int{4, 9}) {
+			t.Errorf("IDs = %v, want [4 9]", got)
+		}
+		if dataset[0].Version != 3 || dataset[1].Notes != "check" {
+			t.Errorf("fields lost: %+v", dataset)
+		}
+		// History of the old item 4 must not attach to the imported one
+		if len(auditLog) != 0 {
+			t.Errorf("audit log kept %d records from the replaced dataset", len(auditLog))
+		}
+	})
+	t.Run("append reassigns taken IDs", func(t *testing.T) {
+		useDataset(t, DataItem{ID: 4, Text: "taken"})
+		if err := importJSON(strings.NewReader(exported), false); err != nil {
+			t.Fatal(err)
+		}
+		got := ids()
+		if len(got) != 3 || got[1] == 4 || got[2] != 9 {
+			t.Errorf("IDs = %v, want the first import reassigned and 9 kept", got)
+		}
+	})
+	t.Run("missing sections", func(t *testing.T) {
+		useDataset(t)
+		if err := importJSON(strings.NewReader(`{"data": []}`), false); err == nil {
+			t.Error("import without metadata succeeded")
+		}
+	})
+}
+
+func TestImportTextDir(t *testing.T) {
+	useDataset(t)
+	root := t.TempDir()
+	files := map[string]string{
+		"top.txt":              "loose",
+		"news/sports/game.txt": "a game",
+		"news/binary.bin":      "nul\x00byte",
+		"reviews/bad.txt":      "\xff\xfe",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	skipped, err := importTextDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 2 {
+		t.Errorf("skipped = %d, want 2", skipped)
+	}
+	got := map[string][2]string{}
+	for _, item := range dataset {
+		got[item.Text] = [2]string{item.Category, item.Label}
+	}
+	want := map[string][2]string{"loose": {"", ""}, "a game": {"news", "sports"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("category, label by text = %v, want %v", got, want)
+	}
+}
+
+func TestSplitTags(t *testing.T) {
+	tests := []struct {
+		value     string
+		dropEmpty bool
+		want      []string
+	}{
+		{"a, b", true, []string{"a", "b"}},
+		{"a,,b,", true, []string{"a", "b"}},
+		{"", true, []string{}},
+		{"a,,b", false, []string{"a", "", "b"}},
+		{"", false, []string{""}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q drop=%v", tt.value, tt.dropEmpty), func(t *testing.T) {
+			dropEmptyTags = tt.dropEmpty
+			defer func() { dropEmptyTags = true }()
+			if got := splitTags(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitTags(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// LabelDefinition describes one label offered by the labeling tab
+type LabelDefinition struct {
+	Name   string `json:"name"`
+	Color  string `json:"color"`  // "#rrggbb", or empty for the theme default
+	Hotkey rune   `json:"hotkey"` // zero for none
+}
+
+// labelCatalog is the label set offered by the labeling tab, in display order
+var labelCatalog = []LabelDefinition{
+	{Name: "positive", Color: "#2e7d32", Hotkey: '1'},
+	{Name: "negative", Color: "#c62828", Hotkey: '2'},
+	{Name: "neutral", Color: "#757575", Hotkey: '3'},
+}
+
+// labelCatalogChanged, when set, is called after labels are added to or
+// removed from the catalog
+var labelCatalogChanged func()
+
+// labelNames lists the catalog's label names in display order
+func labelNames() []string {
+	names := make([]string, len(labelCatalog))
+	for i, def := range labelCatalog {
+		names[i] = def.Name
+	}
+	return names
+}
+
+// addLabelDefinition appends def to the catalog
+// Names and non-zero hotkeys must be unique and the color must parse
+func addLabelDefinition(def LabelDefinition) error {
+	if def.Name == "" {
+		return fmt.Errorf("label name is empty")
+	}
+	if _, err := parseLabelColor(def.Color); err != nil {
+		return err
+	}
+	for _, existing := range labelCatalog {
+		if existing.Name == def.Name {
+			return fmt.Errorf("label %q is already defined", def.Name)
+		}
+		if def.Hotkey != 0 && existing.Hotkey == def.Hotkey {
+			return fmt.Errorf("hotkey %q is already used by %q", def.Hotkey, existing.Name)
+		}
+	}
+	labelCatalog = append(labelCatalog, def)
+	if labelCatalogChanged != nil {
+		labelCatalogChanged()
+	}
+	return nil
+}
+
+// removeLabelDefinition drops the named label from the catalog
+// Items already carrying the label keep it
+func removeLabelDefinition(name string) error {
+	for i, def := range labelCatalog {
+		if def.Name == name {
+			labelCatalog = append(labelCatalog[:i:i], labelCatalog[i+1:]...)
+			if labelCatalogChanged != nil {
+				labelCatalogChanged()
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("label %q is not defined", name)
+}
+
+// parseLabelColor reads a "#rrggbb" color, returning transparent for ""
+func parseLabelColor(value string) (color.NRGBA, error) {
+	if value == "" {
+		return color.NRGBA{}, nil
+	}
+	var c color.NRGBA
+	if len(value) != 7 {
+		return c, fmt.Errorf("invalid color %q, want #rrggbb", value)
+	}
+	if _, err := fmt.Sscanf(value, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return c, fmt.Errorf("invalid color %q, want #rrggbb", value)
+	}
+	c.A = 0xff
+	return c, nil
+}
+
+// searchDebounce is how long the labeling tab waits after a keystroke
+// before running the search
+const searchDebounce = 250 * time.Millisecond
+
+// searchResultLimit caps how many matches the labeling tab lists
+const searchResultLimit = 100
+
+// stepIndex moves current by delta, staying within a dataset of the given length
+func stepIndex(current, delta, length int) int {
+	if length == 0 {
+		return 0
+	}
+	next := current + delta
+	if next < 0 {
+		return 0
+	}
+	if next >= length {
+		return length - 1
+	}
+	return next
+}
+
+// createLabelingTab shows one item at a time for labeling
+// Each catalog label's hotkey assigns it and the arrow keys navigate,
+// but only while isActive reports the tab is showing
+func createLabelingTab(window fyne.Window, isActive func() bool) *fyne.Container {
+	index := 0
+	positionLabel := widget.NewLabel("")
+	labelLabel := widget.NewLabel("")
+	textLabel := widget.NewLabel("")
+	textLabel.Wrapping = fyne.TextWrapWord
+	notesEntry := widget.NewMultiLineEntry()
+	notesEntry.SetPlaceHolder("Notes on this item...")
+	notesEntry.Wrapping = fyne.TextWrapWord
+
+	refresh := func() {
+		datasetMu.RLock()
+		defer datasetMu.RUnlock()
+		if len(dataset) == 0 {
+			positionLabel.SetText("No items")
+			labelLabel.SetText("")
+			textLabel.SetText("")
+			notesEntry.SetText("")
+			return
+		}
+		index = stepIndex(index, 0, len(dataset))
+		item := dataset[index]
+		positionLabel.SetText(fmt.Sprintf("Item %d of %d", index+1, len(dataset)))
+		labelLabel.SetText(fmt.Sprintf("Label: %s", item.Label))
+		textLabel.SetText(item.Text)
+		notesEntry.SetText(item.Notes)
+	}
+
+	// saveNote stores an edited note; navigation calls it first so typed
+	// notes aren't lost
+	saveNote := func() {
+		datasetMu.RLock()
+		valid := index < len(dataset)
+		unchanged := valid && dataset[index].Notes == notesEntry.Text
+		datasetMu.RUnlock()
+		if !valid || unchanged {
+			return
+		}
+		if err := setNote(index, notesEntry.Text); err != nil {
+			dialog.ShowError(err, window)
+		}
+	}
+
+	navigate := func(delta int) {
+		saveNote()
+		datasetMu.RLock()
+		index = stepIndex(index, delta, len(dataset))
+		datasetMu.RUnlock()
+		refresh()
+	}
+
+	assign := func(label string) {
+		datasetMu.RLock()
+		empty := len(dataset) == 0
+		datasetMu.RUnlock()
+		if empty {
+			return
+		}
+		err := updateItem(index, map[string]interface{}{
+			"label":         label,
+			"user_verified": true,
+		})
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		navigate(1)
+	}
+
+	var matchesMu sync.Mutex
+	var matches []int
+	results := widget.NewList(
+		func() int {
+			matchesMu.Lock()
+			defer matchesMu.Unlock()
+			return len(matches)
+		},
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, object fyne.CanvasObject) {
+			matchesMu.Lock()
+			i := -1
+			if id < len(matches) {
+				i = matches[id]
+			}
+			matchesMu.Unlock()
+			datasetMu.RLock()
+			defer datasetMu.RUnlock()
+			if i < 0 || i >= len(dataset) {
+				object.(*widget.Label).SetText("")
+				return
+			}
+			item := dataset[i]
+			text := strings.Join(strings.Fields(item.Text), " ")
+			if runes := []rune(text); len(runes) > 60 {
+				text = string(runes[:60]) + "…"
+			}
+			object.(*widget.Label).SetText(fmt.Sprintf("%d: %s", item.ID, text))
+		},
+	)
+	open := func(i int) {
+		saveNote()
+		index = i
+		refresh()
+	}
+	results.OnSelected = func(id widget.ListItemID) {
+		matchesMu.Lock()
+		i := -1
+		if id < len(matches) {
+			i = matches[id]
+		}
+		matchesMu.Unlock()
+		if i >= 0 {
+			open(i)
+		}
+		results.UnselectAll()
+	}
+
+	debouncer := newQueryDebouncer(searchDebounce, func(query string) {
+		var found []int
+		if strings.TrimSpace(query) != "" {
+			found = search(query)
+		}
+		if len(found) > searchResultLimit {
+			found = found[:searchResultLimit]
+		}
+		matchesMu.Lock()
+		matches = found
+		matchesMu.Unlock()
+		results.Refresh()
+	})
+	searchEntry := widget.NewEntry()
+	searchEntry.SetPlaceHolder("Search to jump to an item...")
+	searchEntry.OnChanged = debouncer.submit
+	// Enter skips the debounce and opens the first match
+	searchEntry.OnSubmitted = func(query string) {
+		debouncer.flush(query)
+		matchesMu.Lock()
+		first := -1
+		if len(matches) > 0 {
+			first = matches[0]
+		}
+		matchesMu.Unlock()
+		if first >= 0 {
+			open(first)
+		}
+	}
+
+	labelButtons := container.NewHBox()
+	buildLabelButtons := func() {
+		labelButtons.RemoveAll()
+		for _, def := range labelCatalog {
+			label := def.Name
+			text := label
+			if def.Hotkey != 0 {
+				text = fmt.Sprintf("%c. %s", def.Hotkey, label)
+			}
+			button := widget.NewButton(text, func() { assign(label) })
+			if c, err := parseLabelColor(def.Color); err == nil && c.A != 0 {
+				button.Importance = widget.LowImportance
+				labelButtons.Add(container.NewStack(canvas.NewRectangle(c), button))
+				continue
+			}
+			labelButtons.Add(button)
+		}
+	}
+	buildLabelButtons()
+	labelCatalogChanged = buildLabelButtons
+
+	window.Canvas().SetOnTypedRune(func(r rune) {
+		if !isActive() {
+			return
+		}
+		for _, def := range labelCatalog {
+			if def.Hotkey != 0 && def.Hotkey == r {
+				assign(def.Name)
+				return
+			}
+		}
+	})
+	window.Canvas().SetOnTypedKey(func(event *fyne.KeyEvent) {
+		if !isActive() {
+			return
+		}
+		switch event.Name {
+		case fyne.KeyLeft, fyne.KeyUp:
+			navigate(-1)
+		case fyne.KeyRight, fyne.KeyDown:
+			navigate(1)
+		}
+	})
+
+	split := container.NewHSplit(container.NewVScroll(textLabel), results)
+	split.Offset = 0.7
+
+	refresh()
+	return container.NewBorder(
+		container.NewVBox(searchEntry, positionLabel, labelLabel),
+		container.NewVBox(
+			container.NewBorder(nil, nil, nil, widget.NewButton("Save Note", saveNote), notesEntry),
+			labelButtons,
+			container.NewHBox(
+				widget.NewButton("← Previous", func() { navigate(-1) }),
+				widget.NewButton("Next →", func() { navigate(1) }),
+			),
+		),
+		nil, nil,
+		split,
+	)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"image/color"
+	"reflect"
+	"testing"
+)
+
+func TestStepIndex(t *testing.T) {
+	tests := []struct {
+		current, delta, length, want int
+	}{
+		{0, 1, 3, 1},
+		{2, 1, 3, 2},
+		{0, -1, 3, 0},
+		{1, 10, 3, 2},
+		{0, 1, 0, 0},
+		{5, -1, 3, 2},
+	}
+	for _, tt := range tests {
+		if got := stepIndex(tt.current, tt.delta, tt.length); got != tt.want {
+			t.Errorf("stepIndex(%d, %d, %d) = %d, want %d", tt.current, tt.delta, tt.length, got, tt.want)
+		}
+	}
+}
+
+func TestLabelCatalog(t *testing.T) {
+	defaults := append([]LabelDefinition(nil), labelCatalog...)
+	changes := 0
+	labelCatalogChanged = func() { changes++ }
+	defer func() { labelCatalog, labelCatalogChanged = defaults, nil }()
+
+	tests := []struct {
+		name    string
+		def     LabelDefinition
+		wantErr bool
+	}{
+		{"new label", LabelDefinition{Name: "mixed", Color: "#ff8800", Hotkey: '4'}, false},
+		{"no color or hotkey", LabelDefinition{Name: "other"}, false},
+		{"empty name", LabelDefinition{Color: "#ffffff"}, true},
+		{"duplicate name", LabelDefinition{Name: "positive"}, true},
+		{"duplicate hotkey", LabelDefinition{Name: "spam", Hotkey: '1'}, true},
+		{"bad color", LabelDefinition{Name: "spam", Color: "red"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := len(labelCatalog)
+			err := addLabelDefinition(tt.def)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if grew := len(labelCatalog) > before; grew == tt.wantErr {
+				t.Errorf("catalog grew = %v after err %v", grew, err)
+			}
+		})
+	}
+
+	want := []string{"positive", "negative", "neutral", "mixed", "other"}
+	if got := labelNames(); !reflect.DeepEqual(got, want) {
+		t.Errorf("labelNames = %v, want %v", got, want)
+	}
+	if err := removeLabelDefinition("negative"); err != nil {
+		t.Fatal(err)
+	}
+	if err := removeLabelDefinition("negative"); err == nil {
+		t.Error("removing a missing label succeeded")
+	}
+	if got := labelNames(); !reflect.DeepEqual(got, []string{"positive", "neutral", "mixed", "other"}) {
+		t.Errorf("after removal labelNames = %v", got)
+	}
+	if defaults[1].Name != "negative" {
+		t.Error("removal changed the catalog's backing array")
+	}
+	// Only the two additions and the removal that succeeded count
+	if changes != 3 {
+		t.Errorf("labelCatalogChanged called %d times, want 3", changes)
+	}
+}
+
+func TestParseLabelColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    color.NRGBA
+		wantErr bool
+	}{
+		{"", color.NRGBA{}, false},
+		{"#2e7d32", color.NRGBA{0x2e, 0x7d, 0x32, 0xff}, false},
+		{"#FFFFFF", color.NRGBA{0xff, 0xff, 0xff, 0xff}, false},
+		{"2e7d32", color.NRGBA{}, true},
+		{"#2e7d3", color.NRGBA{}, true},
+		{"#zzzzzz", color.NRGBA{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseLabelColor(tt.value)
+		if (err != nil) != tt.wantErr || !tt.wantErr && got != tt.want {
+			t.Errorf("parseLabelColor(%q) = %v, %v, want %v, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
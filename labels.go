@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// remapLabels rewrites every label found in mapping to its mapped value
+// and returns how many items changed
+// Items locked by another user are left alone and their IDs returned
+func remapLabels(mapping map[string]string) (changed int, locked []int) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	indices, locked := remapIndices(mapping)
+	now := time.Now()
+	var changes []undoEntry
+	for _, i := range indices {
+		label := mapping[dataset[i].Label]
+		changes = append(changes, writeUpdates(i, map[string]interface{}{"label": label}, now))
+	}
+
+	if len(changes) > 0 {
+		pushUndo(undoEntry{batch: changes})
+		datasetChanged()
+	}
+	return len(changes), locked
+}
+
+// previewRemapLabels returns the indices remapLabels would change, in order,
+// without changing the dataset
+func previewRemapLabels(mapping map[string]string) []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	indices, _ := remapIndices(mapping)
+	return indices
+}
+
+// remapIndices returns the indices of items mapping relabels and, apart,
+// the IDs of those locked by another user
+func remapIndices(mapping map[string]string) (indices, locked []int) {
+	for i, item := range dataset {
+		label, ok := mapping[item.Label]
+		if !ok || label == item.Label {
+			continue
+		}
+		if checkUnlocked(i, currentUser) != nil {
+			locked = append(locked, item.ID)
+			continue
+		}
+		indices = append(indices, i)
+	}
+	return indices, locked
+}
+
+// autoAcceptByConfidence labels every unverified item whose top model
+// prediction scores above threshold with that prediction, marking it with
+// the "auto" review status so a reviewer can still confirm it later
+// It returns how many items changed and the IDs of items it left alone
+// because another user has them locked
+func autoAcceptByConfidence(threshold float64) (changed int, locked []int) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	now := time.Now()
+	var changes []undoEntry
+	for i, item := range dataset {
+		if item.UserVerified {
+			continue
+		}
+		label, score := topPrediction(item.ModelPreds)
+		if label == "" || score <= threshold {
+			continue
+		}
+		if item.Label == label && item.ReviewStatus == "auto" {
+			continue
+		}
+		if checkUnlocked(i, currentUser) != nil {
+			locked = append(locked, item.ID)
+			continue
+		}
+
+		changes = append(changes, writeUpdates(i, map[string]interface{}{
+			"label":         label,
+			"review_status": "auto",
+		}, now))
+	}
+
+	if len(changes) > 0 {
+		pushUndo(undoEntry{batch: changes})
+		datasetChanged()
+	}
+	return len(changes), locked
+}
+
+// suggestLabel returns the label of the verified item whose text shares the
+// most words with the item at index, and the Jaccard similarity of their
+// word sets as a confidence from 0 to 1
+// It returns "" when no other verified item shares any words
+func suggestLabel(index int) (string, float64) {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if index < 0 || index >= len(dataset) {
+		return "", 0
+	}
+	words := wordSet(dataset[index].Text)
+
+	best, bestScore := "", 0.0
+	for i, item := range dataset {
+		if i == index || !item.UserVerified || item.Label == "" {
+			continue
+		}
+		if score := wordSimilarity(words, wordSet(item.Text)); score > bestScore {
+			best, bestScore = item.Label, score
+		}
+	}
+	return best, bestScore
+}
+
+// wordSet returns the distinct lowercase words in text
+func wordSet(text string) map[string]bool {
+	words := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !isWordRune(r) && !unicode.IsMark(r)
+	}) {
+		words[word] = true
+	}
+	return words
+}
+
+// wordSimilarity is the Jaccard index of two word sets, 0 when both are empty
+func wordSimilarity(a, b map[string]bool) float64 {
+	shared := 0
+	for word := range a {
+		if b[word] {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// flagLowConfidence sets the "needs_review" status on unverified items
+// whose top model prediction scores below threshold and returns how many
+// items changed
+// Items without predictions, already flagged or locked by another user
+// are left alone
+func flagLowConfidence(threshold float64) (int, error) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	var indices []int
+	for i, item := range dataset {
+		if item.UserVerified || item.ReviewStatus == "needs_review" || len(item.ModelPreds) == 0 {
+			continue
+		}
+		if _, score := topPrediction(item.ModelPreds); score >= threshold {
+			continue
+		}
+		if checkUnlocked(i, currentUser) != nil {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	if len(indices) == 0 {
+		return 0, nil
+	}
+	if err := applyUpdates(indices, map[string]interface{}{"review_status": "needs_review"}); err != nil {
+		return 0, err
+	}
+	return len(indices), nil
+}
+
+// autoVerifyByConsensus gives the "auto" review status to unverified,
+// labeled items on which at least minModels models score the item's label
+// above minAgreement, and returns how many items changed
+// ModelPreds is read as one score per model for the current label, so
+// items with fewer than minModels entries are skipped
+// UserVerified stays reserved for human checks, as with autoAcceptByConfidence
+func autoVerifyByConsensus(minModels int, minAgreement float64) (int, error) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if minModels < 1 {
+		minModels = 1
+	}
+	var indices []int
+	for i, item := range dataset {
+		if item.UserVerified || item.Label == "" || item.ReviewStatus == "auto" || len(item.ModelPreds) < minModels {
+			continue
+		}
+		agreeing := 0
+		for _, score := range item.ModelPreds {
+			if score > minAgreement {
+				agreeing++
+			}
+		}
+		if agreeing < minModels || checkUnlocked(i, currentUser) != nil {
+			continue
+		}
+		indices = append(indices, i)
+	}
+	if len(indices) == 0 {
+		return 0, nil
+	}
+	if err := applyUpdates(indices, map[string]interface{}{"review_status": "auto"}); err != nil {
+		return 0, err
+	}
+	return len(indices), nil
+}
+
+// addLabel adds label to the item's multi-label set
+// An item without a primary label takes label as its primary
+func addLabel(index int, label string) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return fmt.Errorf("index %d out of range", index)
+	}
+	if label == "" {
+		return fmt.Errorf("empty label")
+	}
+	item := dataset[index]
+	labels := append([]string(nil), item.Labels...)
+	// Items labeled before multi-labels existed only carry the primary
+	if item.Label != "" && !containsString(labels, item.Label) {
+		labels = append([]string{item.Label}, labels...)
+	}
+	if containsString(labels, label) && len(labels) == len(item.Labels) {
+		return nil
+	}
+	if !containsString(labels, label) {
+		labels = append(labels, label)
+	}
+
+	updates := map[string]interface{}{"labels": labels}
+	if item.Label == "" {
+		updates["label"] = label
+	}
+	return applyUpdates([]int{index}, updates)
+}
+
+// removeLabel drops label from the item's multi-label set
+// Removing the primary label promotes the next remaining label, if any
+func removeLabel(index int, label string) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return fmt.Errorf("index %d out of range", index)
+	}
+	item := dataset[index]
+	labels := []string{}
+	for _, existing := range item.Labels {
+		if existing != label {
+			labels = append(labels, existing)
+		}
+	}
+	if len(labels) == len(item.Labels) && item.Label != label {
+		return nil
+	}
+
+	updates := map[string]interface{}{"labels": labels}
+	if item.Label == label {
+		primary := ""
+		if len(labels) > 0 {
+			primary = labels[0]
+		}
+		updates["label"] = primary
+	}
+	return applyUpdates([]int{index}, updates)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRemapLabels(t *testing.T) {
+	tests := []struct {
+		name        string
+		mapping     map[string]string
+		wantChanged int
+		wantLabels  []string
+	}{
+		{"one label", map[string]string{"a": "x"}, 1, []string{"x", "b", "c"}},
+		{"merge two", map[string]string{"a": "b", "c": "b"}, 2, []string{"b", "b", "b"}},
+		{"swap", map[string]string{"a": "b", "b": "a"}, 2, []string{"b", "a", "c"}},
+		{"identity", map[string]string{"a": "a"}, 0, []string{"a", "b", "c"}},
+		{"unused", map[string]string{"z": "a"}, 0, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			if preview := previewRemapLabels(tt.mapping); len(preview) != tt.wantChanged {
+				t.Errorf("preview = %v, want %d indices", preview, tt.wantChanged)
+			}
+			changed, _ := remapLabels(tt.mapping)
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %d, want %d", changed, tt.wantChanged)
+			}
+			if got := labelsOf(); !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", got, tt.wantLabels)
+			}
+			if changed > 0 {
+				if err := undo(); err != nil {
+					t.Fatal(err)
+				}
+				if got := labelsOf(); !reflect.DeepEqual(got, []string{"a", "b", "c"}) {
+					t.Errorf("after undo labels = %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestAutoAcceptByConfidence(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, ModelPreds: map[string]float64{"pos": 0.95, "neg": 0.05}},
+		DataItem{ID: 2, ModelPreds: map[string]float64{"pos": 0.6}},
+		DataItem{ID: 3, Label: "neg", UserVerified: true, ModelPreds: map[string]float64{"pos": 0.99}},
+		DataItem{ID: 4},
+	)
+	changed, _ := autoAcceptByConfidence(0.9)
+	if changed != 1 {
+		t.Errorf("changed = %d, want 1", changed)
+	}
+	if got := labelsOf(); !reflect.DeepEqual(got, []string{"pos", "", "neg", ""}) {
+		t.Errorf("labels = %v", got)
+	}
+	if item := dataset[0]; item.ReviewStatus != "auto" || item.UserVerified || item.Version != 1 {
+		t.Errorf("accepted item = %+v", item)
+	}
+	if changed, _ := autoAcceptByConfidence(0.9); changed != 0 {
+		t.Errorf("second run changed %d items", changed)
+	}
+}
+
+func TestSuggestLabel(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Text: "The café is great"},
+		DataItem{ID: 2, Text: "great café, great food", Label: "pos", UserVerified: true},
+		DataItem{ID: 3, Text: "the service is slow", Label: "neg", UserVerified: true},
+		DataItem{ID: 4, Text: "the café is great", Label: "spam"},
+		DataItem{ID: 5, Text: "nothing shared"},
+	)
+	tests := []struct {
+		index     int
+		wantLabel string
+		wantScore float64
+	}{
+		// {great, café} of {the, café, is, great, food}
+		{0, "pos", 0.4},
+		{4, "", 0},
+		{9, "", 0},
+	}
+	for _, tt := range tests {
+		label, score := suggestLabel(tt.index)
+		if label != tt.wantLabel || !approxEqual(score, tt.wantScore) {
+			t.Errorf("suggestLabel(%d) = %q, %v, want %q, %v", tt.index, label, score, tt.wantLabel, tt.wantScore)
+		}
+	}
+}
+
+func TestFlagLowConfidence(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, ModelPreds: map[string]float64{"a": 0.4}},
+		DataItem{ID: 2, ModelPreds: map[string]float64{"a": 0.9}},
+		DataItem{ID: 3, UserVerified: true, ModelPreds: map[string]float64{"a": 0.1}},
+		DataItem{ID: 4},
+		DataItem{ID: 5, ModelPreds: map[string]float64{"a": 0.2}},
+	)
+	if err := lockItem(4, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	currentUser = "bob"
+
+	if changed, err := flagLowConfidence(0.5); err != nil || changed != 1 {
+		t.Errorf("changed = %d, %v, want 1", changed, err)
+	}
+	var statuses []string
+	for _, item := range dataset {
+		statuses = append(statuses, item.ReviewStatus)
+	}
+	if want := []string{"needs_review", "", "", "", ""}; !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %q, want %q", statuses, want)
+	}
+	if changed, err := flagLowConfidence(0.5); err != nil || changed != 0 {
+		t.Errorf("second run changed %d items, %v", changed, err)
+	}
+}
+
+func TestAutoVerifyByConsensus(t *testing.T) {
+	preds := map[string]float64{"model_a": 0.9, "model_b": 0.8, "model_c": 0.3}
+	tests := []struct {
+		name         string
+		item         DataItem
+		minModels    int
+		minAgreement float64
+		want         int
+	}{
+		{"two of three agree", DataItem{Label: "a", ModelPreds: preds}, 2, 0.75, 1},
+		{"too few agree", DataItem{Label: "a", ModelPreds: preds}, 3, 0.75, 0},
+		{"too few models", DataItem{Label: "a", ModelPreds: map[string]float64{"m": 1}}, 2, 0.5, 0},
+		{"unlabeled", DataItem{ModelPreds: preds}, 1, 0.5, 0},
+		{"already verified", DataItem{Label: "a", UserVerified: true, ModelPreds: preds}, 1, 0.5, 0},
+		{"minimum of one model", DataItem{Label: "a", ModelPreds: preds}, 0, 0.85, 1},
+		{"score equal to minAgreement", DataItem{Label: "a", ModelPreds: preds}, 1, 0.9, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.item.ID = 1
+			useDataset(t, tt.item)
+			got, err := autoVerifyByConsensus(tt.minModels, tt.minAgreement)
+			if err != nil || got != tt.want {
+				t.Errorf("autoVerifyByConsensus = %d, %v, want %d", got, err, tt.want)
+			}
+			if tt.want > 0 && (dataset[0].ReviewStatus != "auto" || dataset[0].UserVerified) {
+				t.Errorf("item = %+v", dataset[0])
+			}
+		})
+	}
+}
+
+func TestMultiLabels(t *testing.T) {
+	type step struct {
+		add         bool
+		label       string
+		wantErr     bool
+		wantPrimary string
+		wantLabels  []string
+	}
+	tests := []struct {
+		name  string
+		item  DataItem
+		steps []step
+	}{
+		{
+			name: "first label becomes primary",
+			item: DataItem{ID: 1},
+			steps: []step{
+				{add: true, label: "a", wantPrimary: "a", wantLabels: []string{"a"}},
+				{add: true, label: "b", wantPrimary: "a", wantLabels: []string{"a", "b"}},
+				{add: true, label: "b", wantPrimary: "a", wantLabels: []string{"a", "b"}},
+				{add: true, label: "", wantErr: true, wantPrimary: "a", wantLabels: []string{"a", "b"}},
+			},
+		},
+		{
+			name: "legacy primary joins the set",
+			item: DataItem{ID: 1, Label: "old"},
+			steps: []step{
+				{add: true, label: "old", wantPrimary: "old", wantLabels: []string{"old"}},
+				{add: true, label: "new", wantPrimary: "old", wantLabels: []string{"old", "new"}},
+			},
+		},
+		{
+			name: "removing the primary promotes the next",
+			item: DataItem{ID: 1, Label: "a", Labels: []string{"a", "b", "c"}},
+			steps: []step{
+				{label: "a", wantPrimary: "b", wantLabels: []string{"b", "c"}},
+				{label: "c", wantPrimary: "b", wantLabels: []string{"b"}},
+				{label: "missing", wantPrimary: "b", wantLabels: []string{"b"}},
+				{label: "b", wantPrimary: "", wantLabels: []string{}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, tt.item)
+			for i, s := range tt.steps {
+				var err error
+				if s.add {
+					err = addLabel(0, s.label)
+				} else {
+					err = removeLabel(0, s.label)
+				}
+				if (err != nil) != s.wantErr {
+					t.Fatalf("step %d: err = %v, wantErr %v", i, err, s.wantErr)
+				}
+				if got := dataset[0]; got.Label != s.wantPrimary || !reflect.DeepEqual(got.Labels, s.wantLabels) {
+					t.Errorf("step %d: primary %q, labels %q, want %q, %q", i, got.Label, got.Labels, s.wantPrimary, s.wantLabels)
+				}
+			}
+		})
+	}
+
+	useDataset(t, DataItem{ID: 1})
+	if err := addLabel(1, "a"); err == nil {
+		t.Error("out of range index accepted")
+	}
+}
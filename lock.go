@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// currentUser is the reviewer using this instance of the app
+// Edits are refused on items locked by anyone else
+var currentUser string
+
+// errItemLocked is returned when an item is locked by another user
+var errItemLocked = errors.New("item locked")
+
+// lockItem reserves the item at index for user until they unlock it
+// Locking an item the user already holds is a no-op
+func lockItem(index int, user string) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return fmt.Errorf("index %d out of range", index)
+	}
+	if user == "" {
+		return errors.New("locking needs a user")
+	}
+	if err := checkUnlocked(index, user); err != nil {
+		return err
+	}
+	dataset[index].Locked = true
+	dataset[index].LockedBy = user
+	return nil
+}
+
+// unlockItem releases the lock user holds on the item at index
+func unlockItem(index int, user string) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return fmt.Errorf("index %d out of range", index)
+	}
+	if !dataset[index].Locked {
+		return nil
+	}
+	if err := checkUnlocked(index, user); err != nil {
+		return err
+	}
+	dataset[index].Locked = false
+	dataset[index].LockedBy = ""
+	return nil
+}
+
+// checkUnlocked fails if the item at index is locked by someone other than user
+func checkUnlocked(index int, user string) error {
+	item := dataset[index]
+	if item.Locked && item.LockedBy != user {
+		return fmt.Errorf("%w: item %d is locked by %s", errItemLocked, item.ID, item.LockedBy)
+	}
+	return nil
+}
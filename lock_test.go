@@ -0,0 +1,153 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestLockItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		locker  string
+		editor  string
+		wantErr error
+	}{
+		{"owner edits", "alice", "alice", nil},
+		{"other user blocked", "alice", "bob", errItemLocked},
+		{"unlocked item", "", "bob", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, DataItem{ID: 1, Label: "a"})
+			if tt.locker != "" {
+				if err := lockItem(0, tt.locker); err != nil {
+					t.Fatal(err)
+				}
+			}
+			currentUser = tt.editor
+			err := updateItem(0, map[string]interface{}{"label": "b"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("updateItem error = %v, want %v", err, tt.wantErr)
+			}
+			want := "b"
+			if tt.wantErr != nil {
+				want = "a"
+			}
+			if got := labelsOf()[0]; got != want {
+				t.Errorf("label = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestUnlockItemOwnerOnly(t *testing.T) {
+	useDataset(t, DataItem{ID: 1})
+	if err := lockItem(0, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if err := unlockItem(0, "bob"); !errors.Is(err, errItemLocked) {
+		t.Fatalf("unlock by bob = %v, want errItemLocked", err)
+	}
+	if err := lockItem(0, "bob"); !errors.Is(err, errItemLocked) {
+		t.Fatalf("lock by bob = %v, want errItemLocked", err)
+	}
+	if err := unlockItem(0, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if dataset[0].Locked || dataset[0].LockedBy != "" {
+		t.Errorf("item still locked: %+v", dataset[0])
+	}
+}
+
+// TestBulkEditsSkipLocked covers the operations that write items directly
+// instead of going through applyUpdates
+func TestBulkEditsSkipLocked(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func() []int
+	}{
+		{"remapLabels", func() []int {
+			_, locked := remapLabels(map[string]string{"a": "z"})
+			return locked
+		}},
+		{"autoAcceptByConfidence", func() []int {
+			_, locked := autoAcceptByConfidence(0.5)
+			return locked
+		}},
+		{"renameTag", func() []int {
+			_, locked := renameTag("t", "renamed")
+			return locked
+		}},
+		{"mergeTags", func() []int {
+			_, locked := mergeTags([]string{"t", "u"}, "merged")
+			return locked
+		}},
+		{"applyAutoTags", func() []int {
+			autoTagRules = nil
+			defer func() { autoTagRules = nil }()
+			if err := addAutoTagRule(AutoTagRule{Pattern: "text", Tag: "auto"}); err != nil {
+				t.Fatal(err)
+			}
+			_, locked := applyAutoTags()
+			return locked
+		}},
+		{"deduplicate", func() []int {
+			_, locked := deduplicate()
+			return locked
+		}},
+		{"mergeDataset", func() []int {
+			locked, err := mergeDataset([]DataItem{
+				{ID: 1, Text: "text", Label: "theirs", UserVerified: true},
+				{ID: 2, Text: "text", Label: "theirs", UserVerified: true},
+			}, nil, MergePreferVerified)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return locked
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := func(id int) DataItem {
+				return DataItem{
+					ID: id, Text: "text", Label: "a", Tags: []string{"t"},
+					ModelPreds: map[string]float64{"b": 0.9},
+				}
+			}
+			useDataset(t, item(1), item(2))
+			if err := lockItem(1, "alice"); err != nil {
+				t.Fatal(err)
+			}
+			lockedItem := dataset[1]
+			currentUser = "bob"
+
+			if locked := tt.run(); !reflect.DeepEqual(locked, []int{2}) {
+				t.Errorf("locked = %v, want [2]", locked)
+			}
+			var got DataItem
+			for _, item := range dataset {
+				if item.ID == 2 {
+					got = item
+				}
+			}
+			if !reflect.DeepEqual(got, lockedItem) {
+				t.Errorf("locked item changed:\n got %+v\nwant %+v", got, lockedItem)
+			}
+		})
+	}
+}
+
+func TestMergeKeepsLock(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Label: "mine"})
+	if err := lockItem(0, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	currentUser = "alice"
+	if _, err := mergeDataset([]DataItem{{ID: 1, Label: "theirs", UserVerified: true}}, nil, MergePreferVerified); err != nil {
+		t.Fatal(err)
+	}
+	if got := dataset[0]; got.Label != "theirs" || !got.Locked || got.LockedBy != "alice" {
+		t.Errorf("merged item = %+v, want label theirs locked by alice", got)
+	}
+}
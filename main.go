@@ -1,32 +1,46 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"reflect"
+	"sort"
 	"time"
 	"strings"
-	"strconv"
+	"sync"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/widget"
-	"fyne.io/fyne/v2/layout"
-	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/data/binding"
-	"image/color"
 )
 
 // DataItem represents a training example
 type DataItem struct {
 	ID          int
 	Text        string
+	RawText     string
 	Category    string
 	Tags        []string
 	Label       string
+	// Labels lists every label of a multi-label item, with Label as the primary
+	Labels      []string
 	Confidence  float64
 	UserVerified bool
+	ReviewStatus string
+	AssignedTo   string
+	Locked       bool
+	LockedBy     string
+	Version      int
 	ModelPreds   map[string]float64
 	LastUpdated  time.Time
+	Notes        string // reviewer's freeform remarks
+	// Deleted marks an item in the trash, moved there at DeletedAt
+	Deleted   bool
+	DeletedAt time.Time
 }
 
 // Dataset holds our training data
@@ -45,22 +59,98 @@ var dataset = []DataItem{
 	// Add more items...
 }
 
+// datasetMu guards dataset, auditLog and the undo history, which the UI
+// and background imports can touch at the same time
+var datasetMu sync.RWMutex
+
+// datasetChangedHook, when set, is called after every dataset change
+// It runs in its own goroutine, as datasetChanged's callers hold datasetMu
+var datasetChangedHook func()
+
+// datasetChanged runs after any operation that modifies the dataset,
+// while the caller still holds datasetMu
+func datasetChanged() {
+	if enableMetricsHistory {
+		appendMetricsSnapshot()
+	}
+	if enableAutosave {
+		noteAutosaveEdit()
+	}
+	if datasetChangedHook != nil {
+		go datasetChangedHook()
+	}
+}
+
 // Training metrics
 type MetricsData struct {
-	Accuracy    float64
-	F1Score     float64
-	DatasetSize int
-	VerifiedPct float64
+	Accuracy          float64
+	F1Score           float64
+	DatasetSize       int
+	VerifiedPct       float64 // items with UserVerified set; kept for existing readers
+	HumanVerifiedPct  float64 // verified items outside the "auto" review status
+	AutoAcceptedPct   float64 // items in the "auto" review status, verified or not
+	LabelDistribution map[string]int
+	// StatusDistribution counts items by ReviewStatus, with "unset" for none
+	StatusDistribution map[string]int
+	DistributionScore  float64
+	QualityScore       float64
+}
+
+// LabelCount is one entry of a sorted label distribution
+type LabelCount struct {
+	Label string
+	Count int
+}
+
+// sortedLabelDistribution lists LabelDistribution by count descending,
+// then label ascending, so displays keep a stable order
+func (m MetricsData) sortedLabelDistribution() []LabelCount {
+	counts := make([]LabelCount, 0, len(m.LabelDistribution))
+	for label, count := range m.LabelDistribution {
+		counts = append(counts, LabelCount{Label: label, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Label < counts[j].Label
+	})
+	return counts
+}
+
+// ChangeRecord is an entry in the audit log
+// Version is the item's version after the change and User who made it
+type ChangeRecord struct {
+	ItemID    int
+	Version   int
+	User      string
+	Field     string
+	OldValue  interface{}
+	NewValue  interface{}
+	Timestamp time.Time
+}
+
+// Audit log of changes to the dataset
+var auditLog []ChangeRecord
+
+// recordChange appends record to the audit log, attributed to currentUser
+func recordChange(record ChangeRecord) {
+	record.User = currentUser
+	auditLog = append(auditLog, record)
 }
 
 func main() {
+	if headlessRequested(os.Args[1:]) {
+		os.Exit(runHeadless(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	myApp := app.New()
 	window := myApp.NewWindow("ML Training Data Review")
 
 	// Bind data for live updates
 	currentIndex := 0
-	metrics := binding.BindFloat("accuracy", 0.0)
-	trainingStatus := binding.BindString("status", "Ready")
+	trainingStatus := binding.NewString()
+	trainingStatus.Set("Ready")
 
 	// Create UI elements
 	textDisplay := widget.NewTextGrid()
@@ -71,7 +161,7 @@ func main() {
 	
 	// Model prediction bars
 	predictionBars := make(map[string]*widget.ProgressBar)
-	for _, label := range []string{"positive", "negative", "neutral"} {
+	for _, label := range labelNames() {
 		predictionBars[label] = widget.NewProgressBar()
 	}
 
@@ -102,25 +192,33 @@ func main() {
 	metricsDisplay := widget.NewTextGrid()
 	updateMetrics := func() {
 		metrics := calculateMetrics()
+		var distribution strings.Builder
+		for _, entry := range metrics.sortedLabelDistribution() {
+			fmt.Fprintf(&distribution, "\n  %s: %d", entry.Label, entry.Count)
+		}
 		metricsText := fmt.Sprintf(
 			"Dataset Metrics:\n"+
 				"Total Examples: %d\n"+
 				"Verified: %.1f%%\n"+
 				"Model Accuracy: %.2f%%\n"+
 				"F1 Score: %.2f\n"+
-				"Last Training: %s",
+				"Last Training: %s\n"+
+				"Labels:%s",
 			metrics.DatasetSize,
 			metrics.VerifiedPct,
 			metrics.Accuracy*100,
 			metrics.F1Score,
 			time.Now().Format("15:04:05"),
+			distribution.String(),
 		)
 		metricsDisplay.SetText(metricsText)
 	}
 
 	// Function to update item display
 	updateDisplay := func(index int) {
+		datasetMu.RLock()
 		item := dataset[index]
+		datasetMu.RUnlock()
 		textDisplay.SetText(item.Text)
 		idLabel.SetText(fmt.Sprintf("ID: %d", item.ID))
 		categoryLabel.SetText(fmt.Sprintf("Category: %s", item.Category))
@@ -160,7 +258,11 @@ func main() {
 	})
 
 	// Create tabs for different views
-	tabs := container.NewAppTabs(
+	var tabs *container.AppTabs
+	labelingTab := createLabelingTab(window, func() bool {
+		return tabs.Selected() != nil && tabs.Selected().Text == "Labeling"
+	})
+	tabs = container.NewAppTabs(
 		container.NewTabItem("Review", createReviewTab(
 			textDisplay, 
 			container.NewVBox(
@@ -173,6 +275,7 @@ func main() {
 			),
 			predictionBars,
 		)),
+		container.NewTabItem("Labeling", labelingTab),
 		container.NewTabItem("Training", container.NewVBox(
 			trainingControls,
 			metricsDisplay,
@@ -186,6 +289,7 @@ func main() {
 		categorySelect,
 		widget.NewButton("Export Data", exportData),
 		widget.NewButton("Import Data", importData),
+		widget.NewButton("Export Report", func() { exportAnalysisReport(window) }),
 	)
 
 	// Main layout
@@ -200,19 +304,213 @@ func main() {
 	// Set window content and show
 	window.SetContent(mainContent)
 	window.Resize(fyne.NewSize(1024, 768))
+	if autosaveRecoverable() {
+		dialog.ShowConfirm("Recover Autosave",
+			"An autosave newer than the last backup was found. Recover it?",
+			func(ok bool) {
+				if !ok {
+					return
+				}
+				if err := recoverAutosave(); err != nil {
+					dialog.ShowError(err, window)
+					return
+				}
+				currentIndex = 0
+				updateDisplay(currentIndex)
+			}, window)
+	}
+	enableAutosave = true
+	autosaveError = func(err error) { dialog.ShowError(err, window) }
+	window.SetOnClosed(flushAutosave)
 	window.ShowAndRun()
 }
 
 // Helper functions (implement these based on your needs)
 func setLabel(index int, label string) {
-	dataset[index].Label = label
-	dataset[index].UserVerified = true
-	dataset[index].LastUpdated = time.Now()
+	updateItem(index, map[string]interface{}{
+		"label":         label,
+		"user_verified": true,
+	})
+}
+
+// setNote replaces the note on the item at index, recording the change
+// in the audit log
+func setNote(index int, note string) error {
+	return updateItem(index, map[string]interface{}{"notes": note})
+}
+
+// Update keys accepted by updateItem and the DataItem fields they set
+var updateFields = map[string]string{
+	"text":          "Text",
+	"category":      "Category",
+	"tags":          "Tags",
+	"label":         "Label",
+	"labels":        "Labels",
+	"confidence":    "Confidence",
+	"user_verified": "UserVerified",
+	"review_status": "ReviewStatus",
+	"assigned_to":   "AssignedTo",
+	"model_preds":   "ModelPreds",
+	"notes":         "Notes",
+}
+
+func updateItem(index int, updates map[string]interface{}) error {
+	return batchUpdate([]int{index}, updates)
+}
+
+// errVersionConflict is returned when an item changed since the caller read it
+var errVersionConflict = errors.New("version conflict")
+
+// updateItemVersioned is updateItem that only applies if the item is
+// still at expectedVersion
+func updateItemVersioned(index, expectedVersion int, updates map[string]interface{}) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	return applyVersionedUpdates(index, expectedVersion, updates)
+}
+
+// applyVersionedUpdates is applyUpdates for one item that must still be at
+// expectedVersion
+// Callers hold datasetMu
+func applyVersionedUpdates(index, expectedVersion int, updates map[string]interface{}) error {
+	if index >= 0 && index < len(dataset) && dataset[index].Version != expectedVersion {
+		return fmt.Errorf("%w: item %d is at version %d, expected %d",
+			errVersionConflict, dataset[index].ID, dataset[index].Version, expectedVersion)
+	}
+	return applyUpdates([]int{index}, updates)
+}
+
+// batchUpdate applies the same updates to every listed item
+// All indices and values are checked first so a bad one changes nothing
+func batchUpdate(indices []int, updates map[string]interface{}) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	return applyUpdates(indices, updates)
+}
+
+func applyUpdates(indices []int, updates map[string]interface{}) error {
+	for _, index := range indices {
+		if index < 0 || index >= len(dataset) {
+			return fmt.Errorf("index %d out of range", index)
+		}
+		if err := checkUnlocked(index, currentUser); err != nil {
+			return err
+		}
+	}
+	if err := checkUpdates(updates); err != nil {
+		return err
+	}
+
+	if len(indices) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	var changes []undoEntry
+	for _, index := range indices {
+		changes = append(changes, writeUpdates(index, updates, now))
+	}
+	pushUndo(undoEntry{batch: changes})
+	datasetChanged()
+	return nil
+}
+
+// checkUpdates fails if updates names an unknown field or gives a value of
+// the wrong type
+func checkUpdates(updates map[string]interface{}) error {
+	itemType := reflect.TypeOf(DataItem{})
+	for key, value := range updates {
+		fieldName, ok := updateFields[key]
+		if !ok {
+			return fmt.Errorf("unknown field %q", key)
+		}
+		field, _ := itemType.FieldByName(fieldName)
+		if reflect.TypeOf(value) != field.Type {
+			return fmt.Errorf("invalid value for %s: %T", key, value)
+		}
+	}
+	return nil
+}
+
+// writeUpdates sets the fields of the item at index through the audit log
+// and returns the change for the undo history
+// Callers hold datasetMu and have checked the index, lock and updates
+func writeUpdates(index int, updates map[string]interface{}, now time.Time) undoEntry {
+	before := dataset[index]
+	item := reflect.ValueOf(&dataset[index]).Elem()
+	for key, value := range updates {
+		field := item.FieldByName(updateFields[key])
+		recordChange(ChangeRecord{
+			ItemID:    dataset[index].ID,
+			Version:   dataset[index].Version + 1,
+			Field:     key,
+			OldValue:  field.Interface(),
+			NewValue:  value,
+			Timestamp: now,
+		})
+		field.Set(reflect.ValueOf(value))
+	}
+	dataset[index].LastUpdated = now
+	dataset[index].Version++
+	return undoEntry{index: index, before: before, after: dataset[index]}
 }
 
 func flagForReview(index int) {
-	dataset[index].Tags = append(dataset[index].Tags, "needs_review")
-	dataset[index].LastUpdated = time.Now()
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return
+	}
+	tags := append(append([]string(nil), dataset[index].Tags...), "needs_review")
+	applyUpdates([]int{index}, map[string]interface{}{"tags": tags})
+}
+
+// deleteItem removes the item at index from the dataset, moving it to the
+// trash unless softDelete is off
+func deleteItem(index int) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if index < 0 || index >= len(dataset) {
+		return fmt.Errorf("index %d out of range", index)
+	}
+	if err := checkUnlocked(index, currentUser); err != nil {
+		return err
+	}
+	now := time.Now()
+	recordChange(ChangeRecord{
+		ItemID:    dataset[index].ID,
+		Field:     "deleted",
+		OldValue:  dataset[index],
+		Timestamp: now,
+	})
+	entry := undoEntry{index: index, deleted: true, before: dataset[index]}
+	if softDelete {
+		entry.trashed = true
+		entry.after = dataset[index]
+		entry.after.Deleted = true
+		entry.after.DeletedAt = now
+		trash = append(trash, entry.after)
+	}
+	pushUndo(entry)
+	retireID(dataset[index].ID)
+	dataset = append(dataset[:index], dataset[index+1:]...)
+	datasetChanged()
+	return nil
+}
+
+// previewDeleteItem returns the item deleteItem would remove without removing it
+func previewDeleteItem(index int) (DataItem, error) {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if index < 0 || index >= len(dataset) {
+		return DataItem{}, fmt.Errorf("index %d out of range", index)
+	}
+	return dataset[index], nil
 }
 
 func filterByCategory(category string) {
@@ -226,21 +524,64 @@ func trainModel(mode string) {
 }
 
 func calculateMetrics() MetricsData {
-	verified := 0
-	for _, item := range dataset {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return metricsFor(dataset)
+}
+
+func metricsFor(items []DataItem) MetricsData {
+	verified, human, auto := 0, 0, 0
+	distribution := make(map[string]int)
+	statuses := make(map[string]int)
+	for _, item := range items {
+		status := item.ReviewStatus
+		if status == "" {
+			status = "unset"
+		}
+		statuses[status]++
+		// A verified item still in the "auto" status was accepted by a
+		// model and only confirmed in passing, so it counts as auto
 		if item.UserVerified {
 			verified++
 		}
+		if item.ReviewStatus == "auto" {
+			auto++
+		} else if item.UserVerified {
+			human++
+		}
+		if item.Label != "" {
+			distribution[item.Label]++
+		}
+		for _, label := range item.Labels {
+			if label != "" && label != item.Label {
+				distribution[label]++
+			}
+		}
+	}
+	percent := func(count int) float64 {
+		if len(items) == 0 {
+			return 0
+		}
+		return float64(count) / float64(len(items)) * 100
 	}
-	return MetricsData{
-		Accuracy:    0.85, // Replace with actual metrics
-		F1Score:     0.83,
-		DatasetSize: len(dataset),
-		VerifiedPct: float64(verified) / float64(len(dataset)) * 100,
+	accuracy, f1 := scoreConfusionMatrix(confusionMatrixFor(items))
+	metrics := MetricsData{
+		Accuracy:           accuracy,
+		F1Score:            f1,
+		DatasetSize:        len(items),
+		VerifiedPct:        percent(verified),
+		HumanVerifiedPct:   percent(human),
+		AutoAcceptedPct:    percent(auto),
+		LabelDistribution:  distribution,
+		StatusDistribution: statuses,
+		DistributionScore:  calculateDistributionScore(distribution),
 	}
+	metrics.QualityScore = qualityScore(metrics, nil)
+	return metrics
 }
 
-func createReviewTab(text *widget.TextGrid, controls fyne.CanvasObject, bars map[string]*widget.ProgressBar) *fyne.Container {
+func createReviewTab(text *widget.TextGrid, controls fyne.CanvasObject, bars map[string]*widget.ProgressBar) fyne.CanvasObject {
 	predictionBox := container.NewVBox()
 	for label, bar := range bars {
 		predictionBox.Add(widget.NewLabel(label))
@@ -257,10 +598,22 @@ func createReviewTab(text *widget.TextGrid, controls fyne.CanvasObject, bars map
 }
 
 func createAnalysisTab() *fyne.Container {
+	// The category bar is redrawn whenever the dataset changes
+	categoryBar := container.NewStack(newCategoryBar())
+	var categoryBarMu sync.Mutex
+	datasetChangedHook = func() {
+		categoryBarMu.Lock()
+		defer categoryBarMu.Unlock()
+		categoryBar.Objects = []fyne.CanvasObject{newCategoryBar()}
+		categoryBar.Refresh()
+	}
+
 	// Create some mock visualizations
 	return container.NewVBox(
 		widget.NewLabel("Distribution of Labels"),
 		widget.NewProgressBar(), // Mock chart
+		widget.NewLabel("Items by Category"),
+		categoryBar,
 		widget.NewLabel("Confidence Over Time"),
 		widget.NewProgressBar(), // Mock chart
 	)
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// useDataset replaces the dataset and everything derived from it with
+// items for the length of the test
+func useDataset(t *testing.T, items ...DataItem) {
+	t.Helper()
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	dataset = items
+	auditLog = nil
+	undoStack, redoStack, importStack = nil, nil, nil
+	trash = nil
+	lastIssuedID = 0
+	currentUser = ""
+	t.Cleanup(func() {
+		datasetMu.Lock()
+		defer datasetMu.Unlock()
+
+		dataset = nil
+		auditLog = nil
+		undoStack, redoStack, importStack = nil, nil, nil
+		trash = nil
+		lastIssuedID = 0
+		currentUser = ""
+	})
+}
+
+// labelsOf lists the dataset's labels in order
+func labelsOf() []string {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	labels := make([]string, len(dataset))
+	for i, item := range dataset {
+		labels[i] = item.Label
+	}
+	return labels
+}
+
+// ids lists the dataset's item IDs in order
+func ids() []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	result := make([]int, len(dataset))
+	for i, item := range dataset {
+		result[i] = item.ID
+	}
+	return result
+}
+
+func threeItems() []DataItem {
+	return []DataItem{
+		{ID: 1, Text: "one", Label: "a"},
+		{ID: 2, Text: "two", Label: "b"},
+		{ID: 3, Text: "three", Label: "c"},
+	}
+}
+
+func TestUpdateItemRecordsOldValue(t *testing.T) {
+	useDataset(t, threeItems()...)
+	currentUser = "alice"
+	if err := updateItem(1, map[string]interface{}{"label": "z"}); err != nil {
+		t.Fatal(err)
+	}
+	want := ChangeRecord{ItemID: 2, Version: 1, User: "alice", Field: "label", OldValue: "b", NewValue: "z"}
+	got := auditLog[0]
+	got.Timestamp = want.Timestamp
+	if len(auditLog) != 1 || !reflect.DeepEqual(got, want) {
+		t.Errorf("audit log = %+v, want [%+v]", auditLog, want)
+	}
+	if dataset[1].Label != "z" || dataset[1].Version != 1 {
+		t.Errorf("item = %+v", dataset[1])
+	}
+}
+
+func TestUpdateItemRejects(t *testing.T) {
+	tests := []struct {
+		name    string
+		index   int
+		updates map[string]interface{}
+	}{
+		{"index out of range", 5, map[string]interface{}{"label": "z"}},
+		{"negative index", -1, map[string]interface{}{"label": "z"}},
+		{"unknown field", 0, map[string]interface{}{"colour": "red"}},
+		{"wrong type", 0, map[string]interface{}{"confidence": "high"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			if err := updateItem(tt.index, tt.updates); err == nil {
+				t.Fatal("expected an error")
+			}
+			if len(auditLog) != 0 || dataset[0].Version != 0 {
+				t.Errorf("rejected update left changes behind")
+			}
+		})
+	}
+}
+
+func TestSetNote(t *testing.T) {
+	useDataset(t, threeItems()...)
+	for _, note := range []string{"first", "second"} {
+		if err := setNote(0, note); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dataset[0].Notes != "second" {
+		t.Errorf("note = %q, want second", dataset[0].Notes)
+	}
+	var changes [][2]interface{}
+	for _, record := range auditLog {
+		if record.Field == "notes" {
+			changes = append(changes, [2]interface{}{record.OldValue, record.NewValue})
+		}
+	}
+	want := [][2]interface{}{{"", "first"}, {"first", "second"}}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("note history = %v, want %v", changes, want)
+	}
+}
+
+func TestDeleteItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		index   int
+		wantIDs []int
+		wantErr bool
+	}{
+		{"first", 0, []int{2, 3}, false},
+		{"middle", 1, []int{1, 3}, false},
+		{"last", 2, []int{1, 2}, false},
+		{"out of range", 3, []int{1, 2, 3}, true},
+		{"negative", -1, []int{1, 2, 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			err := deleteItem(tt.index)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := ids(); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", got, tt.wantIDs)
+			}
+			if tt.wantErr {
+				return
+			}
+			last := auditLog[len(auditLog)-1]
+			if last.Field != "deleted" || last.OldValue.(DataItem).ID != tt.index+1 {
+				t.Errorf("audit entry = %+v", last)
+			}
+		})
+	}
+}
+
+func TestPreviewDeleteItem(t *testing.T) {
+	useDataset(t, threeItems()...)
+	item, err := previewDeleteItem(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if item.ID != 2 {
+		t.Errorf("preview = %+v, want item 2", item)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{1, 2, 3}) || len(auditLog) != 0 || len(trash) != 0 {
+		t.Errorf("preview changed the dataset: IDs %v", got)
+	}
+	if _, err := previewDeleteItem(3); err == nil {
+		t.Error("out of range index accepted")
+	}
+}
+
+func TestBatchUpdate(t *testing.T) {
+	tests := []struct {
+		name       string
+		indices    []int
+		wantErr    bool
+		wantLabels []string
+	}{
+		{"all valid", []int{0, 2}, false, []string{"z", "b", "z"}},
+		{"invalid index changes nothing", []int{0, 7}, true, []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			err := batchUpdate(tt.indices, map[string]interface{}{"label": "z"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := labelsOf(); !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", got, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestDatasetChangedHook(t *testing.T) {
+	useDataset(t, threeItems()...)
+	called := make(chan struct{}, 1)
+	datasetChangedHook = func() {
+		// The hook runs outside the caller's lock, so it can read the dataset
+		datasetMu.RLock()
+		defer datasetMu.RUnlock()
+		called <- struct{}{}
+	}
+	defer func() { datasetChangedHook = nil }()
+
+	setLabel(0, "x")
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		t.Fatal("datasetChangedHook was not called")
+	}
+}
+
+func TestUpdateItemVersioned(t *testing.T) {
+	useDataset(t, threeItems()...)
+	if err := updateItemVersioned(0, 0, map[string]interface{}{"label": "x"}); err != nil {
+		t.Fatalf("current version rejected: %v", err)
+	}
+	err := updateItemVersioned(0, 0, map[string]interface{}{"label": "y"})
+	if !errors.Is(err, errVersionConflict) {
+		t.Fatalf("stale version: err = %v, want errVersionConflict", err)
+	}
+	if dataset[0].Label != "x" || dataset[0].Version != 1 {
+		t.Errorf("item = %+v, want label x at version 1", dataset[0])
+	}
+}
+
+func TestMetricsFor(t *testing.T) {
+	tests := []struct {
+		name         string
+		items        []DataItem
+		wantVerified float64
+		wantHuman    float64
+		wantAuto     float64
+		wantStatuses map[string]int
+	}{
+		{"empty", nil, 0, 0, 0, map[string]int{}},
+		{
+			name: "mixed statuses",
+			items: []DataItem{
+				{Label: "a", UserVerified: true, ReviewStatus: "done"},
+				{Label: "a", ReviewStatus: "auto"},
+				{Label: "b", UserVerified: true, ReviewStatus: "auto"},
+				{Label: "b"},
+			},
+			wantVerified: 50,
+			wantHuman:    25,
+			wantAuto:     50,
+			wantStatuses: map[string]int{"done": 1, "auto": 2, "unset": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := metricsFor(tt.items)
+			for name, value := range map[string]float64{
+				"Accuracy": metrics.Accuracy, "F1Score": metrics.F1Score,
+				"DistributionScore": metrics.DistributionScore, "QualityScore": metrics.QualityScore,
+			} {
+				if math.IsNaN(value) || math.IsInf(value, 0) {
+					t.Errorf("%s = %v", name, value)
+				}
+			}
+			if metrics.VerifiedPct != tt.wantVerified || metrics.HumanVerifiedPct != tt.wantHuman ||
+				metrics.AutoAcceptedPct != tt.wantAuto {
+				t.Errorf("verified, human, auto = %v, %v, %v, want %v, %v, %v",
+					metrics.VerifiedPct, metrics.HumanVerifiedPct, metrics.AutoAcceptedPct,
+					tt.wantVerified, tt.wantHuman, tt.wantAuto)
+			}
+			if !reflect.DeepEqual(metrics.StatusDistribution, tt.wantStatuses) {
+				t.Errorf("statuses = %v, want %v", metrics.StatusDistribution, tt.wantStatuses)
+			}
+		})
+	}
+}
+
+func TestSortedLabelDistribution(t *testing.T) {
+	metrics := MetricsData{LabelDistribution: map[string]int{"b": 2, "a": 2, "c": 5, "d": 1}}
+	want := []LabelCount{{"c", 5}, {"a", 2}, {"b", 2}, {"d", 1}}
+	if got := metrics.sortedLabelDistribution(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestConcurrentMetricsAndUpdates is meant for go test -race
+func TestConcurrentMetricsAndUpdates(t *testing.T) {
+	useDataset(t, threeItems()...)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				updateItem(j%3, map[string]interface{}{"confidence": float64(i)})
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if metrics := calculateMetrics(); metrics.DatasetSize != 3 {
+					t.Errorf("DatasetSize = %d", metrics.DatasetSize)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
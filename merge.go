@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// MergeStrategy decides what mergeDataset does when both sides share an item ID
+type MergeStrategy int
+
+const (
+	MergeReassignIDs    MergeStrategy = iota // keep both, giving incoming items fresh IDs
+	MergePreferVerified                      // keep the verified side, or the existing item on a tie
+	MergePreferNewer                         // keep whichever side was updated last
+)
+
+// mergeDataset combines items and their audit history, typically loaded from
+// another reviewer's session, into the current dataset
+// An incoming ID held by a trashed item, or by an earlier incoming item, gets
+// a fresh ID whatever the strategy, as there is no live item to resolve it
+// against
+// Existing items locked by another user are never replaced; their IDs are
+// returned, and a replaced item keeps its current lock
+// History is kept only for incoming items that were added or replaced an
+// existing one, under the ID they ended up with
+func mergeDataset(items []DataItem, history []ChangeRecord, strategy MergeStrategy) (locked []int, err error) {
+	if strategy < MergeReassignIDs || strategy > MergePreferNewer {
+		return nil, fmt.Errorf("unknown merge strategy %d", strategy)
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	positions := make(map[int]int)
+	for i, item := range dataset {
+		positions[item.ID] = i
+	}
+	used := usedIDs()
+	for _, item := range items {
+		used[item.ID] = true
+	}
+	inTrash := make(map[int]bool, len(trash))
+	for _, item := range trash {
+		inTrash[item.ID] = true
+	}
+
+	kept := make(map[int]int)
+	seen := make(map[int]bool, len(items))
+	now := time.Now()
+	for _, incoming := range items {
+		original := incoming.ID
+		index, collides := positions[original]
+		switch {
+		case seen[original]:
+			// The history can't tell the two apart, so it stays with the first
+			incoming.ID = nextID(used, incoming.Text)
+		case inTrash[original] || collides && strategy == MergeReassignIDs:
+			incoming.ID = nextID(used, incoming.Text)
+			kept[original] = incoming.ID
+		}
+		first := !seen[original]
+		seen[original] = true
+		if incoming.ID != original || !collides {
+			if first && incoming.ID == original {
+				kept[original] = original
+			}
+			dataset = append(dataset, incoming)
+			continue
+		}
+
+		existing := dataset[index]
+		replace := false
+		switch strategy {
+		case MergePreferVerified:
+			replace = incoming.UserVerified && !existing.UserVerified
+		case MergePreferNewer:
+			replace = incoming.LastUpdated.After(existing.LastUpdated)
+		}
+		if replace && checkUnlocked(index, currentUser) != nil {
+			locked = append(locked, existing.ID)
+			continue
+		}
+		if replace {
+			incoming.Locked = existing.Locked
+			incoming.LockedBy = existing.LockedBy
+			recordChange(ChangeRecord{
+				ItemID:    existing.ID,
+				Field:     "merged",
+				OldValue:  existing,
+				NewValue:  incoming,
+				Timestamp: now,
+			})
+			dataset[index] = incoming
+			kept[original] = original
+		}
+	}
+
+	for _, record := range history {
+		id, ok := kept[record.ItemID]
+		if !ok {
+			continue
+		}
+		record.ItemID = id
+		auditLog = append(auditLog, record)
+	}
+
+	// Replaced items would make recorded undo snapshots stale
+	undoStack, redoStack = nil, nil
+	datasetChanged()
+	return locked, nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeDataset(t *testing.T) {
+	older := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Hour)
+	existing := []DataItem{
+		{ID: 1, Text: "one", Label: "mine", LastUpdated: newer},
+		{ID: 2, Text: "two", Label: "mine", UserVerified: true, LastUpdated: older},
+	}
+	tests := []struct {
+		name       string
+		strategy   MergeStrategy
+		incoming   []DataItem
+		wantIDs    []int
+		wantLabels []string
+	}{
+		{
+			name:       "no overlap appends",
+			strategy:   MergePreferVerified,
+			incoming:   []DataItem{{ID: 3, Text: "three", Label: "theirs"}},
+			wantIDs:    []int{1, 2, 3},
+			wantLabels: []string{"mine", "mine", "theirs"},
+		},
+		{
+			name:     "prefer verified replaces unverified only",
+			strategy: MergePreferVerified,
+			incoming: []DataItem{
+				{ID: 1, Text: "one", Label: "theirs", UserVerified: true},
+				{ID: 2, Text: "two", Label: "theirs", UserVerified: true},
+			},
+			wantIDs:    []int{1, 2},
+			wantLabels: []string{"theirs", "mine"},
+		},
+		{
+			name:     "prefer newer",
+			strategy: MergePreferNewer,
+			incoming: []DataItem{
+				{ID: 1, Text: "one", Label: "theirs", LastUpdated: older},
+				{ID: 2, Text: "two", Label: "theirs", LastUpdated: newer},
+			},
+			wantIDs:    []int{1, 2},
+			wantLabels: []string{"mine", "theirs"},
+		},
+		{
+			name:       "reassign keeps both",
+			strategy:   MergeReassignIDs,
+			incoming:   []DataItem{{ID: 1, Text: "one", Label: "theirs"}},
+			wantIDs:    []int{1, 2, 3},
+			wantLabels: []string{"mine", "mine", "theirs"},
+		},
+		{
+			name:     "repeated incoming id gets a fresh one",
+			strategy: MergePreferVerified,
+			incoming: []DataItem{
+				{ID: 5, Text: "five", Label: "first"},
+				{ID: 5, Text: "five again", Label: "second", UserVerified: true},
+			},
+			wantIDs:    []int{1, 2, 5, 3},
+			wantLabels: []string{"mine", "mine", "first", "second"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, append([]DataItem(nil), existing...)...)
+			if _, err := mergeDataset(tt.incoming, nil, tt.strategy); err != nil {
+				t.Fatal(err)
+			}
+			var ids []int
+			for _, item := range dataset {
+				ids = append(ids, item.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if got := labelsOf(); !reflect.DeepEqual(got, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", got, tt.wantLabels)
+			}
+		})
+	}
+}
+
+func TestMergeDatasetRemapsHistory(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "one"})
+	history := []ChangeRecord{{ItemID: 1, Field: "label", NewValue: "theirs"}}
+	if _, err := mergeDataset([]DataItem{{ID: 1, Text: "other"}}, history, MergeReassignIDs); err != nil {
+		t.Fatal(err)
+	}
+	if got := auditLog[len(auditLog)-1].ItemID; got != 2 {
+		t.Errorf("merged history points at item %d, want 2", got)
+	}
+}
+
+func TestMergeDatasetKeepsHistoryOfKeptItems(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Text: "one", UserVerified: true},
+		DataItem{ID: 2, Text: "two"},
+		DataItem{ID: 3, Text: "three", Locked: true, LockedBy: "bob"},
+	)
+	incoming := []DataItem{
+		{ID: 1, Text: "loses"},
+		{ID: 2, Text: "wins", UserVerified: true},
+		{ID: 3, Text: "locked out", UserVerified: true},
+		{ID: 4, Text: "new"},
+	}
+	var history []ChangeRecord
+	for _, id := range []int{1, 2, 3, 4, 5} {
+		history = append(history, ChangeRecord{ItemID: id, Field: "label", NewValue: "theirs"})
+	}
+	if _, err := mergeDataset(incoming, history, MergePreferVerified); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []int
+	for _, record := range auditLog {
+		if record.Field == "label" {
+			got = append(got, record.ItemID)
+		}
+	}
+	if want := []int{2, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("merged history covers items %v, want %v", got, want)
+	}
+}
+
+func TestMergeDatasetTrashedIDCollides(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "one"}, DataItem{ID: 2, Text: "two"})
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+	history := []ChangeRecord{{ItemID: 2, Field: "label", NewValue: "theirs"}}
+	if _, err := mergeDataset([]DataItem{{ID: 2, Text: "incoming"}}, history, MergePreferVerified); err != nil {
+		t.Fatal(err)
+	}
+	if err := restoreDeleted(2); err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int]bool)
+	for _, item := range dataset {
+		if seen[item.ID] {
+			t.Fatalf("ID %d appears twice: %+v", item.ID, dataset)
+		}
+		seen[item.ID] = true
+	}
+	for _, record := range auditLog {
+		if record.Field == "label" && record.ItemID == 2 {
+			t.Errorf("incoming history still points at the trashed item")
+		}
+	}
+}
+
+func TestMergeDatasetUnknownStrategy(t *testing.T) {
+	useDataset(t)
+	if _, err := mergeDataset(nil, nil, MergeStrategy(99)); err == nil {
+		t.Fatal("expected an error")
+	}
+}
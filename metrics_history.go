@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+)
+
+// enableMetricsHistory records a metrics snapshot after every dataset change
+var enableMetricsHistory bool
+
+// metricsSnapshot is the dataset's metrics at one point in time
+type metricsSnapshot struct {
+	Timestamp time.Time
+	Metrics   MetricsData
+}
+
+// metricsHistory holds snapshots in the order they were taken
+var metricsHistory []metricsSnapshot
+
+// recordMetricsSnapshot appends the current metrics to metricsHistory
+func recordMetricsSnapshot() {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	appendMetricsSnapshot()
+}
+
+// appendMetricsSnapshot is recordMetricsSnapshot for callers holding datasetMu
+func appendMetricsSnapshot() {
+	metricsHistory = append(metricsHistory, metricsSnapshot{
+		Timestamp: time.Now(),
+		Metrics:   metricsFor(dataset),
+	})
+}
+
+// exportMetricsHistory writes every snapshot as a CSV row, oldest first
+func exportMetricsHistory(writer io.Writer) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	csvWriter := csv.NewWriter(writer)
+	headers := []string{"timestamp", "dataset_size", "verified_pct", "accuracy", "f1_score", "distribution_score"}
+	if err := csvWriter.Write(headers); err != nil {
+		return err
+	}
+	for _, snapshot := range metricsHistory {
+		metrics := snapshot.Metrics
+		record := []string{
+			snapshot.Timestamp.Format(time.RFC3339Nano),
+			strconv.Itoa(metrics.DatasetSize),
+			strconv.FormatFloat(metrics.VerifiedPct, 'f', -1, 64),
+			strconv.FormatFloat(metrics.Accuracy, 'f', -1, 64),
+			strconv.FormatFloat(metrics.F1Score, 'f', -1, 64),
+			strconv.FormatFloat(metrics.DistributionScore, 'f', -1, 64),
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return err
+		}
+	}
+
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestMetricsHistory(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Label: "a"}, DataItem{ID: 2, Label: "b"})
+	metricsHistory = nil
+	enableMetricsHistory = true
+	defer func() { metricsHistory, enableMetricsHistory = nil, false }()
+
+	recordMetricsSnapshot()
+	setLabel(0, "b")
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+
+	var sizes, verified []float64
+	for _, snapshot := range metricsHistory {
+		sizes = append(sizes, float64(snapshot.Metrics.DatasetSize))
+		verified = append(verified, snapshot.Metrics.VerifiedPct)
+	}
+	if len(metricsHistory) != 3 || sizes[0] != 2 || sizes[2] != 1 || verified[1] != 50 || verified[2] != 100 {
+		t.Fatalf("sizes %v, verified %v", sizes, verified)
+	}
+	for i := 1; i < len(metricsHistory); i++ {
+		if metricsHistory[i].Timestamp.Before(metricsHistory[i-1].Timestamp) {
+			t.Error("snapshots out of order")
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := exportMetricsHistory(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 4 || rows[0][1] != "dataset_size" || rows[3][1] != "1" || rows[3][2] != "100" {
+		t.Errorf("rows = %q", rows)
+	}
+}
+
+func TestMetricsHistoryDisabled(t *testing.T) {
+	useDataset(t, threeItems()...)
+	metricsHistory = nil
+	setLabel(0, "x")
+	if len(metricsHistory) != 0 {
+		t.Errorf("disabled history recorded %d snapshots", len(metricsHistory))
+	}
+}
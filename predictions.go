@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// predictionRecord is one score in a predictions file
+type predictionRecord struct {
+	ID    int     `json:"id"`
+	Label string  `json:"label"`
+	Score float64 `json:"score"`
+}
+
+// importPredictions merges id,label,score rows into the ModelPreds of the
+// items with those IDs, replacing the score of any label already present
+// format is "csv", with a header naming the three columns, or "json", an
+// array of {"id", "label", "score"} objects
+// Labels, tags and verification are left alone; IDs matching no item and
+// IDs of items locked by another user, which are skipped, are returned in
+// ascending order, and nothing is changed if any row is invalid
+func importPredictions(reader io.Reader, format string) (unknown, locked []int, err error) {
+	var records []predictionRecord
+	switch strings.ToLower(format) {
+	case "csv":
+		records, err = readPredictionsCSV(reader)
+	case "json":
+		if err := json.NewDecoder(reader).Decode(&records); err != nil {
+			return nil, nil, fmt.Errorf("parsing predictions: %w", err)
+		}
+		for i, record := range records {
+			if record.Label == "" {
+				return nil, nil, fmt.Errorf("prediction %d: missing label", i+1)
+			}
+		}
+	default:
+		return nil, nil, fmt.Errorf("unknown predictions format %q, want csv or json", format)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	positions := make(map[int]int, len(dataset))
+	for i, item := range dataset {
+		positions[item.ID] = i
+	}
+	scores := make(map[int]map[string]float64)
+	missing := make(map[int]bool)
+	skipped := make(map[int]bool)
+	var order []int
+	for _, record := range records {
+		index, ok := positions[record.ID]
+		if !ok {
+			if !missing[record.ID] {
+				missing[record.ID] = true
+				unknown = append(unknown, record.ID)
+			}
+			continue
+		}
+		if checkUnlocked(index, currentUser) != nil {
+			if !skipped[record.ID] {
+				skipped[record.ID] = true
+				locked = append(locked, record.ID)
+			}
+			continue
+		}
+		if scores[record.ID] == nil {
+			scores[record.ID] = make(map[string]float64)
+			order = append(order, record.ID)
+		}
+		scores[record.ID][record.Label] = record.Score
+	}
+	sort.Ints(unknown)
+	sort.Ints(locked)
+
+	now := time.Now()
+	var changes []undoEntry
+	for _, id := range order {
+		index := positions[id]
+		item := dataset[index]
+		preds := make(map[string]float64, len(item.ModelPreds)+len(scores[id]))
+		for label, score := range item.ModelPreds {
+			preds[label] = score
+		}
+		changed := false
+		for label, score := range scores[id] {
+			if old, ok := preds[label]; !ok || old != score {
+				preds[label] = score
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		changes = append(changes, writeUpdates(index, map[string]interface{}{"model_preds": preds}, now))
+	}
+
+	if len(changes) > 0 {
+		pushUndo(undoEntry{batch: changes})
+		datasetChanged()
+	}
+	return unknown, locked, nil
+}
+
+// readPredictionsCSV reads rows with id, label and score columns, in any
+// order, using the configured or detected delimiter
+func readPredictionsCSV(reader io.Reader) ([]predictionRecord, error) {
+	csvReader, headers, err := openDelimited(reader, csvDelimiter)
+	if err != nil || headers == nil {
+		return nil, err
+	}
+	columns := map[string]int{"id": -1, "label": -1, "score": -1}
+	for i, header := range headers {
+		if _, ok := columns[normalizeHeader(header)]; ok {
+			columns[normalizeHeader(header)] = i
+		}
+	}
+	for _, name := range []string{"id", "label", "score"} {
+		if columns[name] < 0 {
+			return nil, fmt.Errorf("predictions file has no %s column", name)
+		}
+	}
+
+	var records []predictionRecord
+	for row := 1; ; row++ {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		field := func(name string) string {
+			if columns[name] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[columns[name]])
+		}
+
+		id, err := strconv.Atoi(field("id"))
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid id %q", row, field("id"))
+		}
+		label := field("label")
+		if label == "" {
+			return nil, fmt.Errorf("row %d: missing label", row)
+		}
+		score, err := strconv.ParseFloat(field("score"), 64)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: invalid score %q", row, field("score"))
+		}
+		records = append(records, predictionRecord{ID: id, Label: label, Score: score})
+	}
+	return records, nil
+}
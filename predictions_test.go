@@ -0,0 +1,89 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestImportPredictions(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		input       string
+		lockedBy    string
+		wantPreds   map[string]float64
+		wantUnknown []int
+		wantLocked  []int
+		wantErr     bool
+	}{
+		{
+			name:      "csv merges into existing predictions",
+			format:    "csv",
+			input:     "id,label,score\n1,neg,0.3\n1,pos,0.7\n",
+			wantPreds: map[string]float64{"pos": 0.7, "neg": 0.3},
+		},
+		{
+			name:      "json with columns in any order",
+			format:    "json",
+			input:     `[{"score": 0.2, "label": "other", "id": 1}]`,
+			wantPreds: map[string]float64{"pos": 0.9, "other": 0.2},
+		},
+		{
+			name:        "unknown ids are reported",
+			format:      "csv",
+			input:       "id,label,score\n9,pos,0.1\n1,pos,0.5\n7,pos,0.1\n9,neg,0.2\n",
+			wantPreds:   map[string]float64{"pos": 0.5},
+			wantUnknown: []int{7, 9},
+		},
+		{
+			name:       "locked items are skipped",
+			format:     "csv",
+			input:      "id,label,score\n1,pos,0.1\n",
+			lockedBy:   "alice",
+			wantPreds:  map[string]float64{"pos": 0.9},
+			wantLocked: []int{1},
+		},
+		{
+			name:      "invalid score changes nothing",
+			format:    "csv",
+			input:     "id,label,score\n1,pos,0.1\n1,neg,high\n",
+			wantPreds: map[string]float64{"pos": 0.9},
+			wantErr:   true,
+		},
+		{
+			name:      "unknown format",
+			format:    "xml",
+			wantPreds: map[string]float64{"pos": 0.9},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, DataItem{ID: 1, Label: "pos", ModelPreds: map[string]float64{"pos": 0.9}})
+			if tt.lockedBy != "" {
+				if err := lockItem(0, tt.lockedBy); err != nil {
+					t.Fatal(err)
+				}
+				currentUser = "bob"
+			}
+
+			unknown, locked, err := importPredictions(strings.NewReader(tt.input), tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(unknown, tt.wantUnknown) {
+				t.Errorf("unknown = %v, want %v", unknown, tt.wantUnknown)
+			}
+			if !reflect.DeepEqual(locked, tt.wantLocked) {
+				t.Errorf("locked = %v, want %v", locked, tt.wantLocked)
+			}
+			if got := dataset[0].ModelPreds; !reflect.DeepEqual(got, tt.wantPreds) {
+				t.Errorf("ModelPreds = %v, want %v", got, tt.wantPreds)
+			}
+			if dataset[0].Label != "pos" || dataset[0].UserVerified {
+				t.Errorf("label or verification changed: %+v", dataset[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// importProfileDir is where import profiles are stored, one JSON file each
+var importProfileDir = defaultImportProfileDir()
+
+func defaultImportProfileDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "import-profiles"
+	}
+	return filepath.Join(dir, "look-at-the-data", "import-profiles")
+}
+
+// importProfile is the import configuration saveImportProfile persists
+type importProfile struct {
+	Delimiter     rune              `json:"delimiter"`
+	HeaderAliases map[string]string `json:"header_aliases,omitempty"`
+	Normalize     NormalizeOptions  `json:"normalize"`
+	SanitizeText  bool              `json:"sanitize_text"`
+}
+
+// importProfilePath returns the file for the named profile, rejecting names
+// that would point outside importProfileDir
+func importProfilePath(name string) (string, error) {
+	if strings.TrimSpace(name) == "" || name != filepath.Base(name) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid import profile name %q", name)
+	}
+	return filepath.Join(importProfileDir, name+".json"), nil
+}
+
+// saveImportProfile stores the current delimiter, header aliases and text
+// normalization settings under name, replacing any profile of that name
+func saveImportProfile(name string) error {
+	path, err := importProfilePath(name)
+	if err != nil {
+		return err
+	}
+	content, err := json.MarshalIndent(importProfile{
+		Delimiter:     csvDelimiter,
+		HeaderAliases: headerAliases,
+		Normalize:     importNormalize,
+		SanitizeText:  sanitizeText,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(importProfileDir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(path, func(writer io.Writer) error {
+		_, err := writer.Write(content)
+		return err
+	})
+}
+
+// loadImportProfile makes the named profile's settings the ones used by
+// the next import
+func loadImportProfile(name string) error {
+	path, err := importProfilePath(name)
+	if err != nil {
+		return err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading import profile: %w", err)
+	}
+	var profile importProfile
+	if err := json.Unmarshal(content, &profile); err != nil {
+		return fmt.Errorf("parsing import profile %s: %w", path, err)
+	}
+
+	csvDelimiter = profile.Delimiter
+	headerAliases = profile.HeaderAliases
+	importNormalize = profile.Normalize
+	sanitizeText = profile.SanitizeText
+	return nil
+}
+
+// listImportProfiles returns the names of the saved profiles, sorted
+func listImportProfiles() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(importProfileDir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(matches))
+	for i, path := range matches {
+		names[i] = strings.TrimSuffix(filepath.Base(path), ".json")
+	}
+	return names, nil
+}
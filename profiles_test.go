@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestImportProfiles(t *testing.T) {
+	savedDir := importProfileDir
+	importProfileDir = t.TempDir()
+	defer func() {
+		importProfileDir = savedDir
+		csvDelimiter, headerAliases, importNormalize, sanitizeText = 0, nil, NormalizeOptions{}, false
+	}()
+
+	csvDelimiter = ';'
+	headerAliases = map[string]string{"content": "text"}
+	importNormalize = NormalizeOptions{TrimSpace: true, LowerCase: true}
+	sanitizeText = true
+	if err := saveImportProfile("semicolons"); err != nil {
+		t.Fatal(err)
+	}
+	csvDelimiter, headerAliases, importNormalize, sanitizeText = 0, nil, NormalizeOptions{}, false
+	if err := saveImportProfile("defaults"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loadImportProfile("semicolons"); err != nil {
+		t.Fatal(err)
+	}
+	if csvDelimiter != ';' || !reflect.DeepEqual(headerAliases, map[string]string{"content": "text"}) ||
+		importNormalize != (NormalizeOptions{TrimSpace: true, LowerCase: true}) || !sanitizeText {
+		t.Errorf("loaded %q %v %+v %v", csvDelimiter, headerAliases, importNormalize, sanitizeText)
+	}
+	names, err := listImportProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(names, []string{"defaults", "semicolons"}) {
+		t.Errorf("profiles = %v", names)
+	}
+
+	for _, name := range []string{"", " ", ".", "..", "../escape", "a/b", "missing"} {
+		if err := loadImportProfile(name); err == nil {
+			t.Errorf("loadImportProfile(%q) succeeded", name)
+		}
+	}
+	for _, name := range []string{"", "../escape"} {
+		if err := saveImportProfile(name); err == nil {
+			t.Errorf("saveImportProfile(%q) succeeded", name)
+		}
+	}
+	if csvDelimiter != ';' {
+		t.Error("a failed load changed the settings")
+	}
+}
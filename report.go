@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+)
+
+// buildAnalysisReport renders the current metrics as a Markdown document
+func buildAnalysisReport() string {
+	metrics := calculateMetrics()
+
+	var report strings.Builder
+	report.WriteString("# Dataset Analysis Report\n\n")
+	fmt.Fprintf(&report, "- Total examples: %d\n", metrics.DatasetSize)
+	fmt.Fprintf(&report, "- Verified: %.1f%%\n", metrics.VerifiedPct)
+	fmt.Fprintf(&report, "- Auto-accepted: %.1f%%\n", metrics.AutoAcceptedPct)
+	fmt.Fprintf(&report, "- Model accuracy: %.2f%%\n", metrics.Accuracy*100)
+	fmt.Fprintf(&report, "- F1 score: %.2f\n", metrics.F1Score)
+	fmt.Fprintf(&report, "- Distribution score: %.2f\n", metrics.DistributionScore)
+	fmt.Fprintf(&report, "- Quality score: %.2f\n", metrics.QualityScore)
+
+	report.WriteString("\n## Label Distribution\n\n")
+	report.WriteString("| Label | Count |\n")
+	report.WriteString("|-------|-------|\n")
+	for _, entry := range metrics.sortedLabelDistribution() {
+		fmt.Fprintf(&report, "| %s | %d |\n", entry.Label, entry.Count)
+	}
+
+	if warnings := detectSignificantBias(); warnings != "" {
+		report.WriteString("\n## Bias Warnings\n\n")
+		for _, warning := range strings.Split(warnings, "\n") {
+			fmt.Fprintf(&report, "- %s\n", warning)
+		}
+	}
+	return report.String()
+}
+
+// datasetSummary gives a short plain-text overview of the dataset
+// Ties between equally frequent labels go to the alphabetically first
+func datasetSummary() string {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if len(dataset) == 0 {
+		return "No items"
+	}
+
+	verified := 0
+	labels := make(map[string]int)
+	categories := make(map[string]bool)
+	tags := make(map[string]bool)
+	for _, item := range dataset {
+		if item.UserVerified {
+			verified++
+		}
+		if item.Label != "" {
+			labels[item.Label]++
+		}
+		if item.Category != "" {
+			categories[item.Category] = true
+		}
+		for _, tag := range item.Tags {
+			tags[tag] = true
+		}
+	}
+
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "Items: %d\n", len(dataset))
+	fmt.Fprintf(&summary, "Verified: %d (%.1f%%)\n", verified, float64(verified)/float64(len(dataset))*100)
+	fmt.Fprintf(&summary, "Labels: %d, categories: %d, tags: %d", len(labels), len(categories), len(tags))
+
+	most, least := "", ""
+	for label, count := range labels {
+		if most == "" || count > labels[most] || (count == labels[most] && label < most) {
+			most = label
+		}
+		if least == "" || count < labels[least] || (count == labels[least] && label < least) {
+			least = label
+		}
+	}
+	if most != "" {
+		fmt.Fprintf(&summary, "\nMost frequent label: %s (%d)", most, labels[most])
+		fmt.Fprintf(&summary, "\nLeast frequent label: %s (%d)", least, labels[least])
+	}
+	return summary.String()
+}
+
+// exportAnalysisReport asks where to save the Markdown report and writes it there
+func exportAnalysisReport(window fyne.Window) {
+	saveDialog := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			dialog.ShowError(err, window)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		if err := writeAnalysisReport(writer); err != nil {
+			dialog.ShowError(err, window)
+		}
+	}, window)
+	saveDialog.SetFileName("analysis_report.md")
+	saveDialog.Show()
+}
+
+// writeAnalysisReport writes the Markdown report to writer and closes it
+// A failed close can mean the report never reached disk, so it is reported
+// like a failed write
+func writeAnalysisReport(writer io.WriteCloser) error {
+	if _, err := writer.Write([]byte(buildAnalysisReport())); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestBuildAnalysisReport(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Label: "pos", UserVerified: true},
+		DataItem{ID: 2, Label: "neg"},
+	)
+	report := buildAnalysisReport()
+	for _, want := range []string{
+		"# Dataset Analysis Report",
+		"- Total examples: 2",
+		"- Verified: 50.0%",
+		"- Distribution score: 1.00",
+		"- Quality score: 0.75",
+		"| neg | 1 |\n| pos | 1 |",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report is missing %q:\n%s", want, report)
+		}
+	}
+}
+
+// failingCloser records what was written and fails on Close
+type failingCloser struct {
+	strings.Builder
+}
+
+func (failingCloser) Close() error { return errors.New("disk full") }
+
+func TestWriteAnalysisReportReportsCloseError(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Label: "pos"})
+	var writer failingCloser
+	if err := writeAnalysisReport(&writer); err == nil || err.Error() != "disk full" {
+		t.Errorf("err = %v, want the close error", err)
+	}
+	if !strings.Contains(writer.String(), "# Dataset Analysis Report") {
+		t.Errorf("report was not written: %q", writer.String())
+	}
+}
+
+func TestDatasetSummary(t *testing.T) {
+	tests := []struct {
+		name  string
+		items []DataItem
+		want  []string
+	}{
+		{"empty", nil, []string{"No items"}},
+		{
+			name: "populated",
+			items: []DataItem{
+				{ID: 1, Label: "b", Category: "x", Tags: []string{"t"}, UserVerified: true},
+				{ID: 2, Label: "a", Category: "x"},
+				{ID: 3, Label: "b", Category: "y"},
+				{ID: 4, Label: "c"},
+			},
+			want: []string{
+				"Items: 4",
+				"Verified: 1 (25.0%)",
+				"Labels: 3, categories: 2, tags: 1",
+				"Most frequent label: b (2)",
+				"Least frequent label: a (1)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, tt.items...)
+			summary := datasetSummary()
+			for _, want := range tt.want {
+				if !strings.Contains(summary, want) {
+					t.Errorf("summary is missing %q:\n%s", want, summary)
+				}
+			}
+		})
+	}
+}
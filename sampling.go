@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// SamplingStrategy decides which unverified items nextUncertainItems offers first
+type SamplingStrategy int
+
+const (
+	SamplingEntropy         SamplingStrategy = iota // highest prediction entropy
+	SamplingMargin                                  // smallest gap between the top two predictions
+	SamplingLeastConfidence                         // lowest top prediction
+	SamplingRandom                                  // random order
+)
+
+// samplingStrategy is the strategy used by nextUncertainItems
+var samplingStrategy = SamplingEntropy
+
+// nextUncertainItems returns up to n indices of unverified items, most uncertain first
+// Items without predictions come after all items that have them
+func nextUncertainItems(n int) []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	var scored, unscored []int
+	scores := make(map[int]float64)
+	for i, item := range dataset {
+		if item.UserVerified {
+			continue
+		}
+		if len(item.ModelPreds) == 0 {
+			unscored = append(unscored, i)
+			continue
+		}
+		scores[i] = uncertainty(item.ModelPreds, samplingStrategy)
+		scored = append(scored, i)
+	}
+
+	if samplingStrategy == SamplingRandom {
+		rand.Shuffle(len(scored), func(i, j int) { scored[i], scored[j] = scored[j], scored[i] })
+	} else {
+		sort.SliceStable(scored, func(i, j int) bool {
+			return scores[scored[i]] > scores[scored[j]]
+		})
+	}
+
+	results := append(scored, unscored...)
+	if n < 0 {
+		n = 0
+	}
+	if n < len(results) {
+		results = results[:n]
+	}
+	return results
+}
+
+// uncertainty scores a prediction map so that larger values are less certain
+func uncertainty(preds map[string]float64, strategy SamplingStrategy) float64 {
+	switch strategy {
+	case SamplingMargin:
+		first, second := 0.0, 0.0
+		for _, p := range preds {
+			if p > first {
+				first, second = p, first
+			} else if p > second {
+				second = p
+			}
+		}
+		return 1 - (first - second)
+	case SamplingLeastConfidence:
+		_, top := topPrediction(preds)
+		return 1 - top
+	default:
+		total := 0.0
+		for _, p := range preds {
+			total += p
+		}
+		if total == 0 {
+			return 0
+		}
+		entropy := 0.0
+		for _, p := range preds {
+			if p > 0 {
+				q := p / total
+				entropy -= q * math.Log2(q)
+			}
+		}
+		return entropy
+	}
+}
+
+// sampleBalanced picks up to perClass labeled items per label at random,
+// keeping every item of smaller classes, for a class-balanced training set
+// The same seed always yields the same sample, returned in dataset order
+func sampleBalanced(perClass int, seed int64) []DataItem {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	byLabel := make(map[string][]int)
+	for i, item := range dataset {
+		if item.Label != "" {
+			byLabel[item.Label] = append(byLabel[item.Label], i)
+		}
+	}
+	labels := make([]string, 0, len(byLabel))
+	for label := range byLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	if perClass < 0 {
+		perClass = 0
+	}
+	rng := rand.New(rand.NewSource(seed))
+	var picked []int
+	for _, label := range labels {
+		indices := byLabel[label]
+		rng.Shuffle(len(indices), func(i, j int) { indices[i], indices[j] = indices[j], indices[i] })
+		if perClass < len(indices) {
+			indices = indices[:perClass]
+		}
+		picked = append(picked, indices...)
+	}
+	sort.Ints(picked)
+
+	sample := make([]DataItem, len(picked))
+	for i, index := range picked {
+		sample[i] = dataset[index]
+	}
+	return sample
+}
+
+// splitStratified divides the dataset into train and test sets, putting
+// testFraction of each label's items, rounded, in test and the rest in
+// train; unlabeled items form a class of their own
+// The same seed always yields the same split, each returned in dataset order
+// warnings names the labels with too few items to split, which went
+// entirely to train
+func splitStratified(testFraction float64, seed int64) (train, test []DataItem, warnings []string) {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	testFraction = math.Max(0, math.Min(1, testFraction))
+	byLabel := make(map[string][]int)
+	for i, item := range dataset {
+		byLabel[item.Label] = append(byLabel[item.Label], i)
+	}
+	labels := make([]string, 0, len(byLabel))
+	for label := range byLabel {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	rng := rand.New(rand.NewSource(seed))
+	var trainIndices, testIndices []int
+	for _, label := range labels {
+		indices := byLabel[label]
+		n := int(math.Round(float64(len(indices)) * testFraction))
+		if testFraction > 0 && testFraction < 1 && (n == 0 || n == len(indices)) {
+			warnings = append(warnings,
+				fmt.Sprintf("label %q has %d items, too few to split; all kept in train", label, len(indices)))
+			trainIndices = append(trainIndices, indices...)
+			continue
+		}
+		rng.Shuffle(len(indices), func(i, j int) { indices[i], indices[j] = indices[j], indices[i] })
+		testIndices = append(testIndices, indices[:n]...)
+		trainIndices = append(trainIndices, indices[n:]...)
+	}
+	sort.Ints(trainIndices)
+	sort.Ints(testIndices)
+
+	train = make([]DataItem, len(trainIndices))
+	for i, index := range trainIndices {
+		train[i] = dataset[index]
+	}
+	test = make([]DataItem, len(testIndices))
+	for i, index := range testIndices {
+		test[i] = dataset[index]
+	}
+	return train, test, warnings
+}
+
+// exportSplit writes splitStratified's train and test sets as two
+// documents in the exportJSON layout and returns its warnings
+func exportSplit(trainWriter, testWriter io.Writer, testFraction float64, seed int64) ([]string, error) {
+	train, test, warnings := splitStratified(testFraction, seed)
+	if err := writeEnvelope(trainWriter, train); err != nil {
+		return nil, err
+	}
+	if err := writeEnvelope(testWriter, test); err != nil {
+		return nil, err
+	}
+	return warnings, nil
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestUncertaintyEntropy(t *testing.T) {
+	tests := []struct {
+		preds map[string]float64
+		want  float64
+	}{
+		{map[string]float64{"a": 0.5, "b": 0.5}, 1},
+		{map[string]float64{"a": 1}, 0},
+		{map[string]float64{"a": 0.25, "b": 0.25, "c": 0.25, "d": 0.25}, 2},
+		// -(0.75*log2(0.75) + 0.25*log2(0.25))
+		{map[string]float64{"a": 0.75, "b": 0.25}, 0.8112781244591328},
+	}
+	for _, tt := range tests {
+		if got := uncertainty(tt.preds, SamplingEntropy); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("uncertainty(%v) = %v, want %v", tt.preds, got, tt.want)
+		}
+	}
+}
+
+func TestNextUncertainItems(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, ModelPreds: map[string]float64{"a": 0.9, "b": 0.1}},
+		DataItem{ID: 2, ModelPreds: map[string]float64{"a": 0.5, "b": 0.5}},
+		DataItem{ID: 3},
+		DataItem{ID: 4, ModelPreds: map[string]float64{"a": 0.7, "b": 0.3}},
+		DataItem{ID: 5, UserVerified: true, ModelPreds: map[string]float64{"a": 0.5, "b": 0.5}},
+	)
+	tests := []struct {
+		strategy SamplingStrategy
+		n        int
+		want     []int
+	}{
+		{SamplingEntropy, 10, []int{1, 3, 0, 2}},
+		{SamplingMargin, 2, []int{1, 3}},
+		{SamplingLeastConfidence, 10, []int{1, 3, 0, 2}},
+		{SamplingEntropy, -1, []int{}},
+	}
+	defer func() { samplingStrategy = SamplingEntropy }()
+	for _, tt := range tests {
+		samplingStrategy = tt.strategy
+		if got := nextUncertainItems(tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("strategy %d, n %d: got %v, want %v", tt.strategy, tt.n, got, tt.want)
+		}
+	}
+}
+
+// labeledItems returns count items per label, numbered from 1 in the
+// order labels are given
+func labeledItems(counts map[string]int, labels ...string) []DataItem {
+	var items []DataItem
+	for _, label := range labels {
+		for i := 0; i < counts[label]; i++ {
+			items = append(items, DataItem{ID: len(items) + 1, Text: fmt.Sprint(label, i), Label: label})
+		}
+	}
+	return items
+}
+
+func countLabels(items []DataItem) map[string]int {
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Label]++
+	}
+	return counts
+}
+
+func TestSampleBalanced(t *testing.T) {
+	useDataset(t, labeledItems(map[string]int{"big": 10, "small": 2, "": 3}, "big", "small", "")...)
+	tests := []struct {
+		perClass int
+		want     map[string]int
+	}{
+		{3, map[string]int{"big": 3, "small": 2}},
+		{20, map[string]int{"big": 10, "small": 2}},
+		{0, map[string]int{}},
+	}
+	for _, tt := range tests {
+		sample := sampleBalanced(tt.perClass, 7)
+		if got := countLabels(sample); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("perClass %d: counts %v, want %v", tt.perClass, got, tt.want)
+		}
+		if again := sampleBalanced(tt.perClass, 7); !reflect.DeepEqual(sample, again) {
+			t.Errorf("perClass %d: same seed gave different samples", tt.perClass)
+		}
+	}
+}
+
+func TestSplitStratified(t *testing.T) {
+	useDataset(t, labeledItems(map[string]int{"a": 10, "b": 4, "c": 1}, "a", "b", "c")...)
+	tests := []struct {
+		fraction     float64
+		wantTest     map[string]int
+		wantWarnings int
+	}{
+		{0.2, map[string]int{"a": 2, "b": 1}, 1},
+		{0.5, map[string]int{"a": 5, "b": 2}, 1},
+		{0, map[string]int{}, 0},
+		{1, map[string]int{"a": 10, "b": 4, "c": 1}, 0},
+	}
+	for _, tt := range tests {
+		train, test, warnings := splitStratified(tt.fraction, 3)
+		if got := countLabels(test); !reflect.DeepEqual(got, tt.wantTest) {
+			t.Errorf("fraction %v: test counts %v, want %v", tt.fraction, got, tt.wantTest)
+		}
+		if len(train)+len(test) != 15 {
+			t.Errorf("fraction %v: split lost items: %d + %d", tt.fraction, len(train), len(test))
+		}
+		if len(warnings) != tt.wantWarnings {
+			t.Errorf("fraction %v: warnings %q, want %d", tt.fraction, warnings, tt.wantWarnings)
+		}
+		train2, test2, _ := splitStratified(tt.fraction, 3)
+		if !reflect.DeepEqual(train, train2) || !reflect.DeepEqual(test, test2) {
+			t.Errorf("fraction %v: same seed gave different splits", tt.fraction)
+		}
+	}
+}
+
+// TestSplitStratifiedConcurrent is meant for go test -race
+func TestSplitStratifiedConcurrent(t *testing.T) {
+	useDataset(t, labeledItems(map[string]int{"a": 10, "b": 1}, "a", "b")...)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, warnings := splitStratified(0.3, 1); len(warnings) != 1 {
+				t.Errorf("warnings = %q, want one", warnings)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestExportSplit(t *testing.T) {
+	useDataset(t, labeledItems(map[string]int{"a": 4, "b": 1}, "a", "b")...)
+	var train, test bytes.Buffer
+	warnings, err := exportSplit(&train, &test, 0.5, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(warnings) != 1 {
+		t.Errorf("warnings = %q, want one for label b", warnings)
+	}
+	for name, buf := range map[string]*bytes.Buffer{"train": &train, "test": &test} {
+		var doc map[string]interface{}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Errorf("%s is not JSON: %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchOptions narrows how a query is matched against item fields
+type SearchOptions struct {
+	ExactMatch bool // the whole field must equal the query
+	WholeWord  bool // the query must not be part of a larger word
+}
+
+// search returns the indices of items whose text, label, category or tags
+// contain the query, ignoring case
+func search(query string) []int {
+	return searchWithOptions(query, SearchOptions{})
+}
+
+func searchWithOptions(query string, options SearchOptions) []int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	query = strings.ToLower(query)
+
+	var results []int
+	for i, item := range dataset {
+		fields := append([]string{item.Text, item.Label, item.Category}, item.Tags...)
+		for _, field := range fields {
+			if matchField(strings.ToLower(field), query, options) {
+				results = append(results, i)
+				break
+			}
+		}
+	}
+	return results
+}
+
+func matchField(field, query string, options SearchOptions) bool {
+	if options.ExactMatch {
+		return field == query
+	}
+	if !options.WholeWord || query == "" {
+		return strings.Contains(field, query)
+	}
+
+	for start := 0; ; {
+		offset := strings.Index(field[start:], query)
+		if offset < 0 {
+			return false
+		}
+		begin := start + offset
+		end := begin + len(query)
+		if !isWordRuneBefore(field, begin) && !isWordRuneAt(field, end) {
+			return true
+		}
+		start = begin + 1
+	}
+}
+
+func isWordRuneBefore(s string, i int) bool {
+	r, size := utf8.DecodeLastRuneInString(s[:i])
+	return size > 0 && isWordRune(r)
+}
+
+func isWordRuneAt(s string, i int) bool {
+	r, size := utf8.DecodeRuneInString(s[i:])
+	return size > 0 && isWordRune(r)
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// queryDebouncer passes the latest query to dispatch once no newer query
+// has arrived for delay, so typing doesn't search on every keystroke
+type queryDebouncer struct {
+	mu         sync.Mutex
+	delay      time.Duration
+	dispatch   func(string)
+	generation int
+	timer      *time.Timer
+}
+
+func newQueryDebouncer(delay time.Duration, dispatch func(string)) *queryDebouncer {
+	return &queryDebouncer{delay: delay, dispatch: dispatch}
+}
+
+// submit schedules query, replacing any query still waiting
+func (d *queryDebouncer) submit(query string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.generation++
+	generation := d.generation
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.timer = time.AfterFunc(d.delay, func() {
+		d.mu.Lock()
+		current := generation == d.generation
+		d.mu.Unlock()
+		// A timer that fired while being replaced must not dispatch its stale query
+		if current {
+			d.dispatch(query)
+		}
+	})
+}
+
+// flush cancels any waiting query and dispatches query straight away
+func (d *queryDebouncer) flush(query string) {
+	d.mu.Lock()
+	d.generation++
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	d.mu.Unlock()
+	d.dispatch(query)
+}
+
+// listedItems is the dataset, followed by the trash when includeTrash is
+// set; callers hold datasetMu
+func listedItems() []DataItem {
+	if !includeTrash || len(trash) == 0 {
+		return dataset
+	}
+	return append(dataset[:len(dataset):len(dataset)], trash...)
+}
+
+// filterItems returns copies of the items matching pred, in dataset order
+// Trashed items are only considered when includeTrash is set
+func filterItems(pred func(DataItem) bool) []DataItem {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	var results []DataItem
+	for _, item := range listedItems() {
+		if pred(item) {
+			results = append(results, item)
+		}
+	}
+	return results
+}
+
+func filterUnverified() []DataItem {
+	return filterItems(func(item DataItem) bool {
+		return !item.UserVerified
+	})
+}
+
+func filterByStatus(status string) []DataItem {
+	return filterItems(func(item DataItem) bool {
+		return item.ReviewStatus == status
+	})
+}
+
+// page returns copies of up to limit items starting at offset
+// Offsets past the end give an empty page
+func page(offset, limit int) []DataItem {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	items := listedItems()
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 || offset >= len(items) {
+		return []DataItem{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return append([]DataItem(nil), items[offset:end]...)
+}
+
+// pageCount returns how many pages of limit items page can fill
+func pageCount(limit int) int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	if limit <= 0 {
+		return 0
+	}
+	count := len(dataset)
+	if includeTrash {
+		count += len(trash)
+	}
+	return (count + limit - 1) / limit
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSearchWithOptions(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Text: "The cat sat", Label: "Animal"},
+		DataItem{ID: 2, Text: "concatenate strings", Category: "code"},
+		DataItem{ID: 3, Text: "nothing here", Tags: []string{"Cat"}},
+		DataItem{ID: 4, Text: "café_cat"},
+	)
+	tests := []struct {
+		name    string
+		query   string
+		options SearchOptions
+		want    []int
+	}{
+		{"substring ignores case", "CAT", SearchOptions{}, []int{0, 1, 2, 3}},
+		{"label", "animal", SearchOptions{}, []int{0}},
+		{"category", "code", SearchOptions{}, []int{1}},
+		{"whole word", "cat", SearchOptions{WholeWord: true}, []int{0, 2}},
+		{"whole word after accent", "café", SearchOptions{WholeWord: true}, nil},
+		{"exact", "cat", SearchOptions{ExactMatch: true}, []int{2}},
+		{"exact whole field", "the cat", SearchOptions{ExactMatch: true}, nil},
+		{"no match", "dog", SearchOptions{}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := searchWithOptions(tt.query, tt.options); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("searchWithOptions(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryDebouncer(t *testing.T) {
+	var mu sync.Mutex
+	var dispatched []string
+	d := newQueryDebouncer(20*time.Millisecond, func(query string) {
+		mu.Lock()
+		dispatched = append(dispatched, query)
+		mu.Unlock()
+	})
+	got := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), dispatched...)
+	}
+
+	for _, query := range []string{"c", "ca", "cat"} {
+		d.submit(query)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if !reflect.DeepEqual(got(), []string{"cat"}) {
+		t.Fatalf("dispatched = %v, want [cat]", got())
+	}
+
+	d.submit("do")
+	d.flush("dog")
+	time.Sleep(100 * time.Millisecond)
+	if !reflect.DeepEqual(got(), []string{"cat", "dog"}) {
+		t.Errorf("after flush dispatched = %v, want [cat dog]", got())
+	}
+}
+
+func TestFilters(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, UserVerified: true, ReviewStatus: "approved"},
+		DataItem{ID: 2, ReviewStatus: "pending"},
+		DataItem{ID: 3},
+	)
+	trash = []DataItem{{ID: 9, ReviewStatus: "pending"}}
+
+	itemIDs := func(items []DataItem) []int {
+		var got []int
+		for _, item := range items {
+			got = append(got, item.ID)
+		}
+		return got
+	}
+	tests := []struct {
+		name         string
+		includeTrash bool
+		filter       func() []DataItem
+		want         []int
+	}{
+		{"unverified", false, filterUnverified, []int{2, 3}},
+		{"status", false, func() []DataItem { return filterByStatus("pending") }, []int{2}},
+		{"status with trash", true, func() []DataItem { return filterByStatus("pending") }, []int{2, 9}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			includeTrash = tt.includeTrash
+			defer func() { includeTrash = false }()
+			if got := itemIDs(tt.filter()); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("IDs = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPage(t *testing.T) {
+	useDataset(t, DataItem{ID: 1}, DataItem{ID: 2}, DataItem{ID: 3}, DataItem{ID: 4}, DataItem{ID: 5})
+	tests := []struct {
+		offset, limit int
+		want          []int
+	}{
+		{0, 2, []int{1, 2}},
+		{4, 2, []int{5}},
+		{5, 2, nil},
+		{-3, 1, []int{1}},
+		{0, 0, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, item := range page(tt.offset, tt.limit) {
+			got = append(got, item.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("page(%d, %d) = %v, want %v", tt.offset, tt.limit, got, tt.want)
+		}
+	}
+
+	for limit, want := range map[int]int{0: 0, 1: 5, 2: 3, 5: 1, 10: 1} {
+		if got := pageCount(limit); got != want {
+			t.Errorf("pageCount(%d) = %d, want %d", limit, got, want)
+		}
+	}
+
+	// Pages are copies
+	page(0, 1)[0].Label = "changed"
+	if dataset[0].Label != "" {
+		t.Error("editing a page changed the dataset")
+	}
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serverPageLimit is the page size GET /items uses when none is given,
+// and the largest it accepts
+const serverPageLimit = 100
+
+// serverShutdownTimeout bounds how long startServer waits for requests in
+// flight once its context is cancelled
+const serverShutdownTimeout = 5 * time.Second
+
+// itemsPage is the response to GET /items
+type itemsPage struct {
+	Items  []flatRecord `json:"items"`
+	Offset int          `json:"offset"`
+	Limit  int          `json:"limit"`
+	Total  int          `json:"total"`
+}
+
+// startServer serves the dataset as JSON on addr until ctx is cancelled,
+// then lets requests in flight finish and returns nil
+//
+//	GET /items?offset=&limit=  a page of items
+//	GET /items/{id}            one item
+//	PUT /items/{id}            update fields named as in updateItem
+//	GET /metrics               calculateMetrics
+//
+// PUT honours an If-Match header holding the version the client last read
+func startServer(ctx context.Context, addr string) error {
+	server := &http.Server{Addr: addr, Handler: newServerHandler()}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return err
+		}
+		if err := <-errs; !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
+
+// newServerHandler routes the endpoints served by startServer
+func newServerHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items", serveItems)
+	mux.HandleFunc("/items/", serveItem)
+	mux.HandleFunc("/metrics", serveMetrics)
+	return mux
+}
+
+func serveItems(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil || offset < 0 {
+		writeJSONError(w, http.StatusBadRequest, "invalid offset")
+		return
+	}
+	limit, err := queryInt(r, "limit", serverPageLimit)
+	if err != nil || limit <= 0 || limit > serverPageLimit {
+		writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", serverPageLimit))
+		return
+	}
+
+	datasetMu.RLock()
+	response := itemsPage{Items: []flatRecord{}, Offset: offset, Limit: limit, Total: len(dataset)}
+	for i := offset; i < len(dataset) && i < offset+limit; i++ {
+		response.Items = append(response.Items, newFlatRecord(dataset[i]))
+	}
+	datasetMu.RUnlock()
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func serveItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/items/"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, "no such item")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		datasetMu.RLock()
+		index := indexOfID(id)
+		var record flatRecord
+		if index >= 0 {
+			record = newFlatRecord(dataset[index])
+		}
+		datasetMu.RUnlock()
+
+		if index < 0 {
+			writeJSONError(w, http.StatusNotFound, fmt.Sprintf("item %d not found", id))
+			return
+		}
+		writeJSON(w, http.StatusOK, record)
+	case http.MethodPut:
+		updateItemFromRequest(w, r, id)
+	default:
+		writeMethodNotAllowed(w, http.MethodGet, http.MethodPut)
+	}
+}
+
+// updateItemFromRequest applies a JSON object of field updates to the item
+// with the given ID and responds with the updated item
+func updateItemFromRequest(w http.ResponseWriter, r *http.Request, id int) {
+	var body map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "body must be a JSON object: "+err.Error())
+		return
+	}
+	itemType := reflect.TypeOf(DataItem{})
+	updates := make(map[string]interface{}, len(body))
+	for key, value := range body {
+		fieldName, ok := updateFields[key]
+		if !ok {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("unknown field %q", key))
+			return
+		}
+		field, _ := itemType.FieldByName(fieldName)
+		converted, err := convertFieldValue(value, field.Type)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, fmt.Sprintf("invalid value for %s", key))
+			return
+		}
+		updates[key] = converted
+	}
+	expectedVersion := -1
+	if match := r.Header.Get("If-Match"); match != "" {
+		version, err := strconv.Atoi(strings.Trim(match, `"`))
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, "If-Match must hold an item version")
+			return
+		}
+		expectedVersion = version
+	}
+
+	// The lookup and update share one lock so the index can't go stale
+	datasetMu.Lock()
+	index := indexOfID(id)
+	var err error
+	switch {
+	case index < 0:
+		err = fmt.Errorf("item %d not found", id)
+	case expectedVersion >= 0:
+		err = applyVersionedUpdates(index, expectedVersion, updates)
+	default:
+		err = applyUpdates([]int{index}, updates)
+	}
+	var record flatRecord
+	if err == nil {
+		record = newFlatRecord(dataset[index])
+	}
+	datasetMu.Unlock()
+
+	switch {
+	case index < 0:
+		writeJSONError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, errVersionConflict):
+		writeJSONError(w, http.StatusPreconditionFailed, err.Error())
+	case errors.Is(err, errItemLocked):
+		writeJSONError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, record)
+	}
+}
+
+func serveMetrics(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w, http.MethodGet)
+		return
+	}
+	writeJSON(w, http.StatusOK, calculateMetrics())
+}
+
+// indexOfID returns the position of the item with id, or -1; callers hold datasetMu
+func indexOfID(id int) int {
+	for i, item := range dataset {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func queryInt(r *http.Request, name string, fallback int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	return strconv.Atoi(value)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		ifMatch    string
+		wantStatus int
+		wantBody   string
+	}{
+		{"page", "GET", "/items?offset=1&limit=1", "", "", http.StatusOK, `"total":3`},
+		{"page past the end", "GET", "/items?offset=10", "", "", http.StatusOK, `"items":[]`},
+		{"bad offset", "GET", "/items?offset=-1", "", "", http.StatusBadRequest, "invalid offset"},
+		{"limit too large", "GET", "/items?limit=1000", "", "", http.StatusBadRequest, "limit must be"},
+		{"post to items", "POST", "/items", "", "", http.StatusMethodNotAllowed, "method not allowed"},
+		{"one item", "GET", "/items/2", "", "", http.StatusOK, `"text":"two"`},
+		{"missing item", "GET", "/items/9", "", "", http.StatusNotFound, "item 9 not found"},
+		{"bad id", "GET", "/items/x", "", "", http.StatusNotFound, "no such item"},
+		{"update", "PUT", "/items/1", `{"label": "z", "tags": ["t"]}`, "", http.StatusOK, `"label":"z"`},
+		{"update at version", "PUT", "/items/1", `{"label": "z"}`, `"0"`, http.StatusOK, `"version":1`},
+		{"stale version", "PUT", "/items/1", `{"label": "z"}`, "4", http.StatusPreconditionFailed, "version conflict"},
+		{"bad If-Match", "PUT", "/items/1", `{"label": "z"}`, "v1", http.StatusBadRequest, "If-Match"},
+		{"unknown field", "PUT", "/items/1", `{"colour": "red"}`, "", http.StatusBadRequest, `unknown field \"colour\"`},
+		{"wrong type", "PUT", "/items/1", `{"tags": "t"}`, "", http.StatusBadRequest, "invalid value for tags"},
+		{"not an object", "PUT", "/items/1", `[1]`, "", http.StatusBadRequest, "JSON object"},
+		{"update missing item", "PUT", "/items/9", `{"label": "z"}`, "", http.StatusNotFound, "not found"},
+		{"locked item", "PUT", "/items/3", `{"label": "z"}`, "", http.StatusConflict, "locked"},
+		{"delete", "DELETE", "/items/1", "", "", http.StatusMethodNotAllowed, "method not allowed"},
+		{"metrics", "GET", "/metrics", "", "", http.StatusOK, `"DatasetSize":3`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, threeItems()...)
+			if err := lockItem(2, "alice"); err != nil {
+				t.Fatal(err)
+			}
+			currentUser = "bob"
+
+			request := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				request.Header.Set("If-Match", tt.ifMatch)
+			}
+			recorder := httptest.NewRecorder()
+			newServerHandler().ServeHTTP(recorder, request)
+
+			if recorder.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.wantStatus)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			if body := recorder.Body.String(); !strings.Contains(body, tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", body, tt.wantBody)
+			}
+			if !json.Valid(recorder.Body.Bytes()) {
+				t.Errorf("body is not JSON: %s", recorder.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK && tt.method == "PUT" && dataset[0].Label != "a" {
+				t.Errorf("rejected update changed the item: %+v", dataset[0])
+			}
+		})
+	}
+}
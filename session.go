@@ -0,0 +1,103 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// sessionVersion is bumped whenever the session file layout changes
+const sessionVersion = 1
+
+// sessionFile is everything saveSession persists between runs
+type sessionFile struct {
+	Version         int               `json:"version"`
+	Data            []DataItem        `json:"data"`
+	AuditLog        []ChangeRecord    `json:"audit_log"`
+	BackupPath      string            `json:"backup_path"`
+	MaxBackups      int               `json:"max_backups"`
+	CSVDelimiter    rune              `json:"csv_delimiter"`
+	DedupIgnoreCase bool              `json:"dedup_ignore_case"`
+	LabelCatalog    []LabelDefinition `json:"label_catalog,omitempty"`
+	LastIssuedID    int               `json:"last_issued_id"`
+	Trash           []DataItem        `json:"trash,omitempty"`
+	CurrentUser     string            `json:"current_user,omitempty"`
+}
+
+// saveSession writes the dataset, audit log, settings and current user to path
+func saveSession(path string) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	content, err := json.MarshalIndent(sessionFile{
+		Version:         sessionVersion,
+		Data:            dataset,
+		AuditLog:        auditLog,
+		BackupPath:      backupPath,
+		MaxBackups:      maxBackups,
+		CSVDelimiter:    csvDelimiter,
+		DedupIgnoreCase: dedupIgnoreCase,
+		LabelCatalog:    labelCatalog,
+		LastIssuedID:    lastIssuedID,
+		Trash:           trash,
+		CurrentUser:     currentUser,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = writeFileAtomic(path, func(writer io.Writer) error {
+		_, err := writer.Write(content)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if abs, err := filepath.Abs(path); err == nil {
+		savedSessionSums.Store(abs, sha256.Sum256(content))
+	}
+	return nil
+}
+
+// loadSession replaces the current session with one written by saveSession
+func loadSession(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading session: %w", err)
+	}
+
+	var session sessionFile
+	if err := json.Unmarshal(content, &session); err != nil {
+		return fmt.Errorf("parsing session %s: %w", path, err)
+	}
+	if session.Version < 1 || session.Version > sessionVersion {
+		return fmt.Errorf("session %s has unsupported version %d", path, session.Version)
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+	dataset = session.Data
+	auditLog = session.AuditLog
+	backupPath = session.BackupPath
+	if backupPath == "" {
+		backupPath = "backups"
+	}
+	maxBackups = session.MaxBackups
+	csvDelimiter = session.CSVDelimiter
+	dedupIgnoreCase = session.DedupIgnoreCase
+	// Sessions saved before the catalog existed keep the current one
+	if session.LabelCatalog != nil {
+		labelCatalog = session.LabelCatalog
+		if labelCatalogChanged != nil {
+			labelCatalogChanged()
+		}
+	}
+	lastIssuedID = session.LastIssuedID
+	trash = session.Trash
+	currentUser = session.CurrentUser
+	undoStack, redoStack, importStack = nil, nil, nil
+	datasetChanged()
+	return nil
+}
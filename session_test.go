@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSessionRoundTrip(t *testing.T) {
+	updated := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	useDataset(t,
+		DataItem{ID: 1, Text: "one", Label: "pos", Tags: []string{"t"}, LastUpdated: updated},
+		DataItem{ID: 2, Text: "two", Label: "neg", UserVerified: true, LastUpdated: updated},
+	)
+	currentUser = "alice"
+	if err := updateItem(0, map[string]interface{}{"label": "neg"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+	savedBackupPath := backupPath
+	backupPath = "elsewhere"
+	defer func() { backupPath = savedBackupPath }()
+
+	path := filepath.Join(t.TempDir(), "session.json")
+	if err := saveSession(path); err != nil {
+		t.Fatal(err)
+	}
+	wantData := append([]DataItem(nil), dataset...)
+	wantTrash := append([]DataItem(nil), trash...)
+	wantAudit := len(auditLog)
+	wantIssued := lastIssuedID
+
+	useDataset(t)
+	backupPath = "backups"
+	if err := loadSession(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if currentUser != "alice" {
+		t.Errorf("currentUser = %q, want alice", currentUser)
+	}
+	if backupPath != "elsewhere" {
+		t.Errorf("backupPath = %q, want elsewhere", backupPath)
+	}
+	if lastIssuedID != wantIssued {
+		t.Errorf("lastIssuedID = %d, want %d", lastIssuedID, wantIssued)
+	}
+	if len(auditLog) != wantAudit {
+		t.Errorf("audit log has %d entries, want %d", len(auditLog), wantAudit)
+	}
+	for _, items := range [][]DataItem{dataset, trash, wantData, wantTrash} {
+		for i := range items {
+			items[i].LastUpdated = items[i].LastUpdated.UTC().Round(0)
+			items[i].DeletedAt = items[i].DeletedAt.UTC().Round(0)
+		}
+	}
+	if !reflect.DeepEqual(dataset, wantData) {
+		t.Errorf("dataset = %+v, want %+v", dataset, wantData)
+	}
+	if !reflect.DeepEqual(trash, wantTrash) {
+		t.Errorf("trash = %+v, want %+v", trash, wantTrash)
+	}
+}
+
+func TestLoadSessionRejectsVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"too new", `{"version": 99, "data": []}`},
+		{"missing version", `{"data": []}`},
+		{"not JSON", `{`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, DataItem{ID: 1})
+			path := filepath.Join(t.TempDir(), "session.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := loadSession(path); err == nil {
+				t.Fatal("expected an error")
+			}
+			if len(dataset) != 1 {
+				t.Errorf("failed load changed the dataset")
+			}
+		})
+	}
+}
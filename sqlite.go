@@ -0,0 +1,275 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const sqliteSchema = `
+CREATE TABLE items (
+	id            INTEGER PRIMARY KEY,
+	text          TEXT NOT NULL,
+	raw_text      TEXT NOT NULL,
+	category      TEXT NOT NULL,
+	label         TEXT NOT NULL,
+	labels        TEXT NOT NULL, -- JSON array
+	tags          TEXT NOT NULL, -- JSON array
+	confidence    REAL NOT NULL,
+	user_verified INTEGER NOT NULL,
+	review_status TEXT NOT NULL,
+	assigned_to   TEXT NOT NULL,
+	version       INTEGER NOT NULL,
+	model_preds   TEXT NOT NULL, -- JSON object
+	last_updated  TEXT NOT NULL,
+	notes         TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE history (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	item_id   INTEGER NOT NULL REFERENCES items(id),
+	version   INTEGER NOT NULL,
+	user      TEXT NOT NULL,
+	field     TEXT NOT NULL,
+	old_value TEXT, -- JSON
+	new_value TEXT, -- JSON
+	timestamp TEXT NOT NULL
+);
+
+CREATE INDEX history_item ON history(item_id);
+`
+
+// exportSQLite writes the dataset and its audit log to a new SQLite database
+// at path, replacing any file already there
+// Tags, labels, predictions and audited values are stored as JSON text
+// Audit entries for items no longer in the dataset are left out, since the
+// history table references items
+func exportSQLite(path string) error {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+	if err := writeSQLite(tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+func writeSQLite(path string) error {
+	db, err := sql.Open("sqlite3", "file:"+path+"?_foreign_keys=on")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	insertItem, err := tx.Prepare(`INSERT INTO items (id, text, raw_text, category, label, labels, tags,
+		confidence, user_verified, review_status, assigned_to, version, model_preds, last_updated, notes)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertItem.Close()
+
+	ids := make(map[int]bool)
+	for _, item := range dataset {
+		labels, err := jsonColumn(item.Labels, "[]")
+		if err != nil {
+			return err
+		}
+		tags, err := jsonColumn(item.Tags, "[]")
+		if err != nil {
+			return err
+		}
+		preds, err := jsonColumn(item.ModelPreds, "{}")
+		if err != nil {
+			return err
+		}
+		_, err = insertItem.Exec(item.ID, item.Text, item.RawText, item.Category, item.Label, labels, tags,
+			item.Confidence, item.UserVerified, item.ReviewStatus, item.AssignedTo, item.Version,
+			preds, item.LastUpdated.Format(time.RFC3339Nano), item.Notes)
+		if err != nil {
+			return err
+		}
+		ids[item.ID] = true
+	}
+
+	insertChange, err := tx.Prepare(`INSERT INTO history (item_id, version, user, field, old_value, new_value, timestamp)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer insertChange.Close()
+
+	for _, record := range auditLog {
+		if !ids[record.ItemID] {
+			continue
+		}
+		oldValue, err := json.Marshal(record.OldValue)
+		if err != nil {
+			return err
+		}
+		newValue, err := json.Marshal(record.NewValue)
+		if err != nil {
+			return err
+		}
+		_, err = insertChange.Exec(record.ItemID, record.Version, record.User, record.Field,
+			string(oldValue), string(newValue), record.Timestamp.Format(time.RFC3339Nano))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// importSQLite replaces the dataset and audit log with those stored in a
+// database written by exportSQLite
+func importSQLite(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, table := range []string{"items", "history"} {
+		var name string
+		err := db.QueryRow(`SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&name)
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("%s has no %s table", path, table)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	items, err := readSQLiteItems(db)
+	if err != nil {
+		return fmt.Errorf("reading items: %w", err)
+	}
+	history, err := readSQLiteHistory(db)
+	if err != nil {
+		return fmt.Errorf("reading history: %w", err)
+	}
+
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+	dataset = items
+	auditLog = history
+	undoStack, redoStack, importStack = nil, nil, nil
+	// Trashed items belonged to the dataset being replaced
+	trash = nil
+	datasetChanged()
+	return nil
+}
+
+func readSQLiteItems(db *sql.DB) ([]DataItem, error) {
+	// Files exported before items had notes lack the column
+	var hasNotes bool
+	err := db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('items') WHERE name = 'notes'`).Scan(&hasNotes)
+	if err != nil {
+		return nil, err
+	}
+	notes := "notes"
+	if !hasNotes {
+		notes = "''"
+	}
+
+	rows, err := db.Query(`SELECT id, text, raw_text, category, label, labels, tags, confidence,
+		user_verified, review_status, assigned_to, version, model_preds, last_updated, ` + notes + `
+		FROM items ORDER BY rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []DataItem
+	for rows.Next() {
+		var item DataItem
+		var labels, tags, preds, updated string
+		err := rows.Scan(&item.ID, &item.Text, &item.RawText, &item.Category, &item.Label, &labels, &tags,
+			&item.Confidence, &item.UserVerified, &item.ReviewStatus, &item.AssignedTo,
+			&item.Version, &preds, &updated, &item.Notes)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(labels), &item.Labels); err != nil {
+			return nil, fmt.Errorf("item %d labels: %w", item.ID, err)
+		}
+		if err := json.Unmarshal([]byte(tags), &item.Tags); err != nil {
+			return nil, fmt.Errorf("item %d tags: %w", item.ID, err)
+		}
+		if err := json.Unmarshal([]byte(preds), &item.ModelPreds); err != nil {
+			return nil, fmt.Errorf("item %d model_preds: %w", item.ID, err)
+		}
+		if item.LastUpdated, err = time.Parse(time.RFC3339Nano, updated); err != nil {
+			return nil, fmt.Errorf("item %d last_updated: %w", item.ID, err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func readSQLiteHistory(db *sql.DB) ([]ChangeRecord, error) {
+	rows, err := db.Query(`SELECT item_id, version, user, field, old_value, new_value, timestamp
+		FROM history ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []ChangeRecord
+	for rows.Next() {
+		var record ChangeRecord
+		var oldValue, newValue sql.NullString
+		var timestamp string
+		err := rows.Scan(&record.ItemID, &record.Version, &record.User, &record.Field,
+			&oldValue, &newValue, &timestamp)
+		if err != nil {
+			return nil, err
+		}
+		if oldValue.Valid {
+			if err := json.Unmarshal([]byte(oldValue.String), &record.OldValue); err != nil {
+				return nil, fmt.Errorf("item %d old value: %w", record.ItemID, err)
+			}
+		}
+		if newValue.Valid {
+			if err := json.Unmarshal([]byte(newValue.String), &record.NewValue); err != nil {
+				return nil, fmt.Errorf("item %d new value: %w", record.ItemID, err)
+			}
+		}
+		if record.Timestamp, err = time.Parse(time.RFC3339Nano, timestamp); err != nil {
+			return nil, fmt.Errorf("item %d timestamp: %w", record.ItemID, err)
+		}
+		history = append(history, record)
+	}
+	return history, rows.Err()
+}
+
+// jsonColumn encodes value for a JSON text column, using empty for nil
+func jsonColumn(value interface{}, empty string) (string, error) {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	if string(content) == "null" {
+		return empty, nil
+	}
+	return string(content), nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func sqliteItems() []DataItem {
+	updated := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	return []DataItem{
+		{
+			ID: 1, Text: "one", RawText: " one ", Category: "c", Label: "pos", Labels: []string{"pos", "x"},
+			Tags: []string{"t"}, Confidence: 0.8, UserVerified: true, ReviewStatus: "done",
+			AssignedTo: "alice", Version: 2, ModelPreds: map[string]float64{"pos": 0.8},
+			LastUpdated: updated, Notes: "check the wording",
+		},
+		{
+			ID: 2, Text: "two", Labels: []string{}, Tags: []string{}, ModelPreds: map[string]float64{},
+			LastUpdated: updated,
+		},
+	}
+}
+
+func TestExportSQLiteVerifiedCount(t *testing.T) {
+	useDataset(t, sqliteItems()...)
+	path := filepath.Join(t.TempDir(), "data.db")
+	if err := exportSQLite(path); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var verified int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM items WHERE user_verified`).Scan(&verified); err != nil {
+		t.Fatal(err)
+	}
+	if verified != 1 {
+		t.Errorf("verified = %d, want 1", verified)
+	}
+}
+
+func TestSQLiteRoundTrip(t *testing.T) {
+	useDataset(t, sqliteItems()...)
+	if err := setNote(1, "second note"); err != nil {
+		t.Fatal(err)
+	}
+	want := append([]DataItem(nil), dataset...)
+	wantHistory := len(auditLog)
+
+	path := filepath.Join(t.TempDir(), "data.db")
+	if err := exportSQLite(path); err != nil {
+		t.Fatal(err)
+	}
+	useDataset(t)
+	if err := importSQLite(path); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range want {
+		if !dataset[i].LastUpdated.Equal(want[i].LastUpdated) {
+			t.Errorf("item %d LastUpdated = %v, want %v", i, dataset[i].LastUpdated, want[i].LastUpdated)
+		}
+		dataset[i].LastUpdated = want[i].LastUpdated
+	}
+	if !reflect.DeepEqual(dataset, want) {
+		t.Errorf("round trip changed the dataset:\n got %+v\nwant %+v", dataset, want)
+	}
+	if len(auditLog) != wantHistory {
+		t.Fatalf("history has %d entries, want %d", len(auditLog), wantHistory)
+	}
+	if got := auditLog[0]; got.Field != "notes" || got.OldValue != "" || got.NewValue != "second note" {
+		t.Errorf("history entry = %+v", got)
+	}
+}
+
+func TestImportSQLiteWithoutNotesColumn(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`
+		CREATE TABLE items (id INTEGER PRIMARY KEY, text TEXT, raw_text TEXT, category TEXT, label TEXT,
+			labels TEXT, tags TEXT, confidence REAL, user_verified INTEGER, review_status TEXT,
+			assigned_to TEXT, version INTEGER, model_preds TEXT, last_updated TEXT);
+		CREATE TABLE history (id INTEGER PRIMARY KEY, item_id INTEGER, version INTEGER, user TEXT,
+			field TEXT, old_value TEXT, new_value TEXT, timestamp TEXT);
+		INSERT INTO items VALUES (1, 'old', '', '', 'pos', '[]', '[]', 0, 0, '', '', 0, '{}',
+			'2024-01-01T00:00:00Z');`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useDataset(t)
+	if err := importSQLite(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(dataset) != 1 || dataset[0].Text != "old" || dataset[0].Notes != "" {
+		t.Errorf("dataset = %+v", dataset)
+	}
+}
+
+func TestImportSQLiteMissingTable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY)`)
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	useDataset(t, DataItem{ID: 1})
+	if err := importSQLite(path); err == nil {
+		t.Fatal("expected an error for a file without a history table")
+	}
+	if len(dataset) != 1 {
+		t.Errorf("failed import changed the dataset")
+	}
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// renameTag replaces oldTag with newTag on every item and returns how many
+// items changed, as mergeTags does
+func renameTag(oldTag, newTag string) (changed int, locked []int) {
+	return mergeTags([]string{oldTag}, newTag)
+}
+
+// mergeTags replaces every source tag with target across the dataset,
+// dropping duplicates this creates, and returns how many items changed
+// Items locked by another user are left alone and their IDs returned
+func mergeTags(sources []string, target string) (changed int, locked []int) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	isSource := make(map[string]bool)
+	for _, tag := range sources {
+		isSource[tag] = true
+	}
+
+	now := time.Now()
+	var changes []undoEntry
+	for i, item := range dataset {
+		touched := false
+		seen := make(map[string]bool)
+		var tags []string
+		for _, tag := range item.Tags {
+			if isSource[tag] {
+				tag = target
+				touched = true
+			}
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+		if !touched {
+			continue
+		}
+		if checkUnlocked(i, currentUser) != nil {
+			locked = append(locked, item.ID)
+			continue
+		}
+
+		changes = append(changes, writeUpdates(i, map[string]interface{}{"tags": tags}, now))
+	}
+
+	if len(changes) > 0 {
+		pushUndo(undoEntry{batch: changes})
+		datasetChanged()
+	}
+	return len(changes), locked
+}
+
+// AutoTagRule adds Tag to items whose text contains Pattern, ignoring case,
+// or matches it as a regular expression when Regex is set
+type AutoTagRule struct {
+	Pattern string
+	Tag     string
+	Regex   bool
+
+	compiled *regexp.Regexp
+}
+
+// autoTagRules are the rules run by applyAutoTags
+var autoTagRules []AutoTagRule
+
+// addAutoTagRule checks and stores a rule for applyAutoTags
+func addAutoTagRule(rule AutoTagRule) error {
+	if rule.Tag == "" {
+		return fmt.Errorf("auto-tag rule for %q has no tag", rule.Pattern)
+	}
+	if rule.Regex {
+		compiled, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid auto-tag pattern %q: %w", rule.Pattern, err)
+		}
+		rule.compiled = compiled
+	}
+	autoTagRules = append(autoTagRules, rule)
+	return nil
+}
+
+// applyAutoTags adds the tag of every matching rule to each item that
+// doesn't already have it and returns how many items changed
+// Items locked by another user are left alone and their IDs returned
+func applyAutoTags() (changed int, locked []int) {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	now := time.Now()
+	var changes []undoEntry
+	for i, item := range dataset {
+		has := make(map[string]bool)
+		for _, tag := range item.Tags {
+			has[tag] = true
+		}
+
+		tags := append([]string(nil), item.Tags...)
+		lowerText := strings.ToLower(item.Text)
+		for _, rule := range autoTagRules {
+			if has[rule.Tag] {
+				continue
+			}
+			matched := false
+			if rule.compiled != nil {
+				matched = rule.compiled.MatchString(item.Text)
+			} else {
+				matched = strings.Contains(lowerText, strings.ToLower(rule.Pattern))
+			}
+			if matched {
+				has[rule.Tag] = true
+				tags = append(tags, rule.Tag)
+			}
+		}
+		if len(tags) == len(item.Tags) {
+			continue
+		}
+		if checkUnlocked(i, currentUser) != nil {
+			locked = append(locked, item.ID)
+			continue
+		}
+
+		changes = append(changes, writeUpdates(i, map[string]interface{}{"tags": tags}, now))
+	}
+
+	if len(changes) > 0 {
+		pushUndo(undoEntry{batch: changes})
+		datasetChanged()
+	}
+	return len(changes), locked
+}
+
+// tagCooccurrence counts, for every pair of distinct tags, how many items
+// carry both; counts[a][b] always equals counts[b][a]
+// Repeated tags on one item count once
+func tagCooccurrence() map[string]map[string]int {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	counts := make(map[string]map[string]int)
+	seen := make(map[string]bool)
+	var tags []string
+	var rows []map[string]int
+	for _, item := range dataset {
+		tags = tags[:0]
+		for _, tag := range item.Tags {
+			if tag != "" && !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+		for _, tag := range tags {
+			delete(seen, tag)
+		}
+
+		if len(tags) < 2 {
+			continue
+		}
+
+		// Looking each row up once keeps the pair loop to two increments
+		rows = rows[:0]
+		for _, tag := range tags {
+			row := counts[tag]
+			if row == nil {
+				row = make(map[string]int)
+				counts[tag] = row
+			}
+			rows = append(rows, row)
+		}
+		for i, a := range tags {
+			for j := i + 1; j < len(tags); j++ {
+				rows[i][tags[j]]++
+				rows[j][a]++
+			}
+		}
+	}
+	return counts
+}
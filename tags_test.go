@@ -0,0 +1,169 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// tagsOf lists the tags of each item in order
+func tagsOf(items []DataItem) [][]string {
+	tags := make([][]string, len(items))
+	for i, item := range items {
+		tags[i] = item.Tags
+	}
+	return tags
+}
+
+func TestMergeTags(t *testing.T) {
+	items := func() []DataItem {
+		return []DataItem{
+			{ID: 1, Tags: []string{"spam", "junk", "keep"}},
+			{ID: 2, Tags: []string{"Junk"}},
+			{ID: 3, Tags: []string{"junk"}},
+		}
+	}
+	tests := []struct {
+		name        string
+		run         func() (int, []int)
+		wantChanged int
+		wantTags    [][]string
+	}{
+		{
+			name:        "rename",
+			run:         func() (int, []int) { return renameTag("junk", "trash") },
+			wantChanged: 2,
+			wantTags:    [][]string{{"spam", "trash", "keep"}, {"Junk"}, {"trash"}},
+		},
+		{
+			name:        "merge drops duplicates",
+			run:         func() (int, []int) { return mergeTags([]string{"spam", "junk"}, "unwanted") },
+			wantChanged: 2,
+			wantTags:    [][]string{{"unwanted", "keep"}, {"Junk"}, {"unwanted"}},
+		},
+		{
+			name:        "into an existing tag",
+			run:         func() (int, []int) { return mergeTags([]string{"junk"}, "keep") },
+			wantChanged: 2,
+			wantTags:    [][]string{{"spam", "keep"}, {"Junk"}, {"keep"}},
+		},
+		{
+			name:     "unused tag",
+			run:      func() (int, []int) { return renameTag("none", "x") },
+			wantTags: [][]string{{"spam", "junk", "keep"}, {"Junk"}, {"junk"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t, items()...)
+			changed, _ := tt.run()
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %d, want %d", changed, tt.wantChanged)
+			}
+			if got := tagsOf(dataset); !reflect.DeepEqual(got, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", got, tt.wantTags)
+			}
+			if changed > 0 {
+				if err := undo(); err != nil {
+					t.Fatal(err)
+				}
+				if got := tagsOf(dataset); !reflect.DeepEqual(got, tagsOf(items())) {
+					t.Errorf("after undo tags = %v", got)
+				}
+			}
+		})
+	}
+}
+
+func TestApplyAutoTags(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []AutoTagRule
+		wantErr     bool
+		wantChanged int
+		wantTags    [][]string
+	}{
+		{
+			name:        "substring ignores case",
+			rules:       []AutoTagRule{{Pattern: "REFUND", Tag: "billing"}},
+			wantChanged: 1,
+			wantTags:    [][]string{{"billing"}, nil, {"billing"}},
+		},
+		{
+			name:        "regex",
+			rules:       []AutoTagRule{{Pattern: `\d{3}-\d{4}`, Tag: "phone", Regex: true}},
+			wantChanged: 1,
+			wantTags:    [][]string{nil, {"phone"}, {"billing"}},
+		},
+		{
+			name: "several rules",
+			rules: []AutoTagRule{
+				{Pattern: "refund", Tag: "billing"},
+				{Pattern: "call", Tag: "contact"},
+			},
+			wantChanged: 2,
+			wantTags:    [][]string{{"billing", "contact"}, {"contact"}, {"billing"}},
+		},
+		{
+			name:    "invalid regex",
+			rules:   []AutoTagRule{{Pattern: "(", Tag: "x", Regex: true}},
+			wantErr: true,
+		},
+		{
+			name:    "no tag",
+			rules:   []AutoTagRule{{Pattern: "refund"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t,
+				DataItem{ID: 1, Text: "Refund please, call me"},
+				DataItem{ID: 2, Text: "call 555-1234"},
+				DataItem{ID: 3, Text: "refund", Tags: []string{"billing"}},
+			)
+			autoTagRules = nil
+			defer func() { autoTagRules = nil }()
+			for _, rule := range tt.rules {
+				if err := addAutoTagRule(rule); err != nil {
+					if !tt.wantErr {
+						t.Fatal(err)
+					}
+					if len(autoTagRules) != 0 {
+						t.Error("rejected rule was stored")
+					}
+					return
+				}
+			}
+			if tt.wantErr {
+				t.Fatal("invalid rule accepted")
+			}
+
+			changed, _ := applyAutoTags()
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %d, want %d", changed, tt.wantChanged)
+			}
+			if got := tagsOf(dataset); !reflect.DeepEqual(got, tt.wantTags) {
+				t.Errorf("tags = %v, want %v", got, tt.wantTags)
+			}
+			if changed, _ := applyAutoTags(); changed != 0 {
+				t.Errorf("second run changed %d items", changed)
+			}
+		})
+	}
+}
+
+func TestTagCooccurrence(t *testing.T) {
+	useDataset(t,
+		DataItem{ID: 1, Tags: []string{"a", "b", "c"}},
+		DataItem{ID: 2, Tags: []string{"a", "b", "b", ""}},
+		DataItem{ID: 3, Tags: []string{"a"}},
+	)
+	want := map[string]map[string]int{
+		"a": {"b": 2, "c": 1},
+		"b": {"a": 2, "c": 1},
+		"c": {"a": 1, "b": 1},
+	}
+	if got := tagCooccurrence(); !reflect.DeepEqual(got, want) {
+		t.Errorf("tagCooccurrence = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// softDelete makes deleteItem move items to the trash instead of
+// discarding them
+var softDelete = true
+
+// includeTrash makes filterItems and page cover trashed items too, after
+// the dataset's own
+var includeTrash bool
+
+// trash holds deleted items, oldest first, until restoreDeleted or emptyTrash
+// Being outside dataset keeps them out of metrics, exports and searches
+var trash []DataItem
+
+// trashedItems returns copies of the items in the trash
+func trashedItems() []DataItem {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return append([]DataItem(nil), trash...)
+}
+
+// restoreDeleted moves the trashed item with id back to the end of the dataset
+// Undo history is cleared, as undoing the deletion would now add it twice
+func restoreDeleted(id int) error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	item, ok := removeFromTrash(id)
+	if !ok {
+		return fmt.Errorf("item %d is not in the trash", id)
+	}
+	item.Deleted = false
+	item.DeletedAt = time.Time{}
+	recordChange(ChangeRecord{
+		ItemID:    id,
+		Field:     "restored",
+		NewValue:  item,
+		Timestamp: time.Now(),
+	})
+	dataset = append(dataset, item)
+	undoStack, redoStack = nil, nil
+	datasetChanged()
+	return nil
+}
+
+// emptyTrash permanently discards every trashed item and returns how many
+// there were
+// Undo history is cleared so a deletion can't be undone after its purge
+func emptyTrash() int {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	now := time.Now()
+	for _, item := range trash {
+		recordChange(ChangeRecord{
+			ItemID:    item.ID,
+			Field:     "purged",
+			OldValue:  item,
+			Timestamp: now,
+		})
+	}
+	purged := len(trash)
+	trash = nil
+	if purged > 0 {
+		undoStack, redoStack = nil, nil
+	}
+	return purged
+}
+
+// removeFromTrash takes the item with id out of the trash; callers hold datasetMu
+func removeFromTrash(id int) (DataItem, bool) {
+	for i, item := range trash {
+		if item.ID == id {
+			trash = append(trash[:i], trash[i+1:]...)
+			return item, true
+		}
+	}
+	return DataItem{}, false
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrash(t *testing.T) {
+	useDataset(t, threeItems()...)
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteItem(0); err != nil {
+		t.Fatal(err)
+	}
+	trashed := trashedItems()
+	if len(trashed) != 2 || trashed[0].ID != 2 || !trashed[0].Deleted || trashed[0].DeletedAt.IsZero() {
+		t.Fatalf("trash = %+v", trashed)
+	}
+
+	if err := restoreDeleted(2); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{3, 2}) {
+		t.Errorf("IDs after restore = %v, want [3 2]", got)
+	}
+	if restored := dataset[1]; restored.Deleted || !restored.DeletedAt.IsZero() {
+		t.Errorf("restored item still marked deleted: %+v", restored)
+	}
+	if len(undoStack) != 0 {
+		t.Error("restore kept the undo history")
+	}
+	if err := restoreDeleted(2); err == nil {
+		t.Error("restoring an item twice succeeded")
+	}
+
+	if purged := emptyTrash(); purged != 1 {
+		t.Errorf("purged = %d, want 1", purged)
+	}
+	if len(trashedItems()) != 0 || emptyTrash() != 0 {
+		t.Error("trash not empty after purge")
+	}
+	if last := auditLog[len(auditLog)-1]; last.Field != "purged" || last.ItemID != 1 {
+		t.Errorf("last audit entry = %+v", last)
+	}
+}
+
+func TestHardDelete(t *testing.T) {
+	useDataset(t, threeItems()...)
+	softDelete = false
+	defer func() { softDelete = true }()
+
+	if err := deleteItem(0); err != nil {
+		t.Fatal(err)
+	}
+	if len(trash) != 0 {
+		t.Errorf("hard delete trashed %d items", len(trash))
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{2, 3}) {
+		t.Errorf("IDs = %v, want [2 3]", got)
+	}
+}
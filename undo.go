@@ -0,0 +1,251 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// undoDepth caps how many operations can be undone
+var undoDepth = 50
+
+// undoEntry captures an item before and after an updateItem or deleteItem call
+// An entry with a batch undoes all of its changes together
+type undoEntry struct {
+	index   int
+	deleted bool
+	trashed bool // a deletion that moved after, the trashed copy, to the trash
+	before  DataItem
+	after   DataItem
+	batch   []undoEntry
+}
+
+var undoStack []undoEntry
+var redoStack []undoEntry
+
+// pushUndo records a new operation and discards anything that could be redone
+func pushUndo(entry undoEntry) {
+	undoStack = append(undoStack, entry)
+	if len(undoStack) > undoDepth {
+		undoStack = undoStack[len(undoStack)-undoDepth:]
+	}
+	redoStack = nil
+}
+
+func undo() error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if len(undoStack) == 0 {
+		return errors.New("nothing to undo")
+	}
+	entry := undoStack[len(undoStack)-1]
+	if err := checkEntryUnlocked(entry); err != nil {
+		return err
+	}
+	undoStack = undoStack[:len(undoStack)-1]
+
+	revertEntry(entry, time.Now())
+	redoStack = append(redoStack, entry)
+	datasetChanged()
+	return nil
+}
+
+func redo() error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if len(redoStack) == 0 {
+		return errors.New("nothing to redo")
+	}
+	entry := redoStack[len(redoStack)-1]
+	if err := checkEntryUnlocked(entry); err != nil {
+		return err
+	}
+	redoStack = redoStack[:len(redoStack)-1]
+
+	applyEntry(entry, time.Now())
+	undoStack = append(undoStack, entry)
+	datasetChanged()
+	return nil
+}
+
+// checkEntryUnlocked fails if another user has locked an item that undoing
+// or redoing entry would change
+// Items are found by ID, as a batch's indices shift while it is replayed
+func checkEntryUnlocked(entry undoEntry) error {
+	ids := make(map[int]bool)
+	var collect func(undoEntry)
+	collect = func(entry undoEntry) {
+		for _, change := range entry.batch {
+			collect(change)
+		}
+		if len(entry.batch) == 0 {
+			ids[entry.before.ID] = true
+		}
+	}
+	collect(entry)
+
+	for i, item := range dataset {
+		if ids[item.ID] {
+			if err := checkUnlocked(i, currentUser); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// revertEntry takes back entry, recording each change in the audit log
+func revertEntry(entry undoEntry, now time.Time) {
+	if len(entry.batch) > 0 {
+		for i := len(entry.batch) - 1; i >= 0; i-- {
+			revertEntry(entry.batch[i], now)
+		}
+		return
+	}
+
+	if entry.deleted {
+		dataset = append(dataset, DataItem{})
+		copy(dataset[entry.index+1:], dataset[entry.index:])
+		dataset[entry.index] = entry.before
+		if entry.trashed {
+			removeFromTrash(entry.before.ID)
+		}
+		recordChange(ChangeRecord{
+			ItemID:    entry.before.ID,
+			Field:     "restored",
+			NewValue:  entry.before,
+			Timestamp: now,
+		})
+	} else {
+		restoreFields(entry.index, entry.before, now)
+	}
+}
+
+// applyEntry repeats entry after it was undone, recording each change in
+// the audit log
+func applyEntry(entry undoEntry, now time.Time) {
+	if len(entry.batch) > 0 {
+		for _, change := range entry.batch {
+			applyEntry(change, now)
+		}
+		return
+	}
+
+	if entry.deleted {
+		recordChange(ChangeRecord{
+			ItemID:    entry.before.ID,
+			Field:     "deleted",
+			OldValue:  dataset[entry.index],
+			Timestamp: now,
+		})
+		dataset = append(dataset[:entry.index], dataset[entry.index+1:]...)
+		if entry.trashed {
+			trash = append(trash, entry.after)
+		}
+	} else {
+		restoreFields(entry.index, entry.after, now)
+	}
+}
+
+// restoreFields gives the item at index the field values of a recorded
+// snapshot through writeUpdates, so each change is audited and the version
+// moves forward rather than back to the snapshot's
+// Locks are not part of an item's history, so the current lock is kept
+func restoreFields(index int, snapshot DataItem, now time.Time) {
+	current := reflect.ValueOf(dataset[index])
+	recorded := reflect.ValueOf(snapshot)
+	updates := make(map[string]interface{})
+	for key, name := range updateFields {
+		value := recorded.FieldByName(name).Interface()
+		if !reflect.DeepEqual(current.FieldByName(name).Interface(), value) {
+			updates[key] = value
+		}
+	}
+	if len(updates) > 0 {
+		writeUpdates(index, updates, now)
+	}
+}
+
+// importUndo records what one import changed, for undoImport
+type importUndo struct {
+	added   map[int]bool                   // IDs of the items it appended
+	updated map[int]map[string]interface{} // prior values of the fields it changed, by item ID
+}
+
+// importStack holds the imports undoImport can take back, most recent last
+var importStack []importUndo
+
+// pushImport records an import; callers hold datasetMu
+func pushImport(entry importUndo) {
+	importStack = append(importStack, entry)
+	if len(importStack) > undoDepth {
+		importStack = importStack[len(importStack)-undoDepth:]
+	}
+}
+
+// undoImport takes back the most recent import still on the stack: the
+// items it appended are removed and the fields it updated are restored,
+// both through the audit log
+// Items deleted since are skipped; locked ones stop the undo before any
+// change is made
+// Like any deletion, removing the appended items leaves their IDs retired
+func undoImport() error {
+	datasetMu.Lock()
+	defer datasetMu.Unlock()
+
+	if len(importStack) == 0 {
+		return errors.New("no import to undo")
+	}
+	entry := importStack[len(importStack)-1]
+
+	positions := make(map[int]int, len(dataset))
+	for i, item := range dataset {
+		positions[item.ID] = i
+	}
+	for id := range entry.added {
+		if index, ok := positions[id]; ok {
+			if err := checkUnlocked(index, currentUser); err != nil {
+				return err
+			}
+		}
+	}
+	for id := range entry.updated {
+		if index, ok := positions[id]; ok && !entry.added[id] {
+			if err := checkUnlocked(index, currentUser); err != nil {
+				return err
+			}
+		}
+	}
+	importStack = importStack[:len(importStack)-1]
+
+	for id, values := range entry.updated {
+		if index, ok := positions[id]; ok && !entry.added[id] {
+			if err := applyUpdates([]int{index}, values); err != nil {
+				return fmt.Errorf("item %d: %w", id, err)
+			}
+		}
+	}
+
+	now := time.Now()
+	kept := dataset[:0]
+	for _, item := range dataset {
+		if !entry.added[item.ID] {
+			kept = append(kept, item)
+			continue
+		}
+		recordChange(ChangeRecord{
+			ItemID:    item.ID,
+			Field:     "deleted",
+			OldValue:  item,
+			Timestamp: now,
+		})
+	}
+	dataset = kept
+	// Indices recorded for undo no longer line up with the dataset
+	undoStack, redoStack = nil, nil
+	datasetChanged()
+	return nil
+}
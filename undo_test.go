@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUndoRedo(t *testing.T) {
+	useDataset(t, threeItems()...)
+	setLabel(0, "x")
+	setLabel(0, "y")
+
+	steps := []struct {
+		name       string
+		run        func() error
+		wantLabels []string
+		wantErr    bool
+	}{
+		{"undo second change", undo, []string{"x", "b", "c"}, false},
+		{"undo first change", undo, []string{"a", "b", "c"}, false},
+		{"undo past the start", undo, []string{"a", "b", "c"}, true},
+		{"redo first change", redo, []string{"x", "b", "c"}, false},
+		{"redo second change", redo, []string{"y", "b", "c"}, false},
+		{"redo past the end", redo, []string{"y", "b", "c"}, true},
+	}
+	for _, step := range steps {
+		err := step.run()
+		if (err != nil) != step.wantErr {
+			t.Fatalf("%s: err = %v, wantErr %v", step.name, err, step.wantErr)
+		}
+		if got := labelsOf(); !reflect.DeepEqual(got, step.wantLabels) {
+			t.Fatalf("%s: labels = %v, want %v", step.name, got, step.wantLabels)
+		}
+	}
+}
+
+func TestUndoNewEditClearsRedo(t *testing.T) {
+	useDataset(t, threeItems()...)
+	setLabel(0, "x")
+	if err := undo(); err != nil {
+		t.Fatal(err)
+	}
+	setLabel(1, "y")
+	if err := redo(); err == nil {
+		t.Error("redo after a new edit should fail")
+	}
+}
+
+func TestUndoDepth(t *testing.T) {
+	useDataset(t, threeItems()...)
+	undoDepth = 2
+	defer func() { undoDepth = 50 }()
+
+	for _, label := range []string{"x", "y", "z"} {
+		setLabel(0, label)
+	}
+	for i := 0; i < 2; i++ {
+		if err := undo(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := undo(); err == nil {
+		t.Error("undo beyond undoDepth should fail")
+	}
+	if got := labelsOf()[0]; got != "x" {
+		t.Errorf("label = %q, want x", got)
+	}
+}
+
+func TestUndoDelete(t *testing.T) {
+	useDataset(t, threeItems()...)
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := undo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf("IDs after undo = %v", got)
+	}
+	if len(trash) != 0 {
+		t.Errorf("trash after undo = %+v", trash)
+	}
+	if err := redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{1, 3}) || len(trash) != 1 {
+		t.Errorf("after redo: IDs %v, trash %d", got, len(trash))
+	}
+}
+
+func TestUndoRespectsLocks(t *testing.T) {
+	useDataset(t, threeItems()...)
+	setLabel(0, "x")
+	if err := lockItem(0, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := undo(); !errors.Is(err, errItemLocked) {
+		t.Fatalf("undo of a locked item: err = %v, want errItemLocked", err)
+	}
+	if got := labelsOf()[0]; got != "x" {
+		t.Errorf("label = %q, want x", got)
+	}
+	if err := unlockItem(0, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := lockItem(0, "bob"); err != nil {
+		t.Fatal(err)
+	}
+	if err := redo(); !errors.Is(err, errItemLocked) {
+		t.Errorf("redo of a locked item: err = %v, want errItemLocked", err)
+	}
+}
+
+func TestUndoRecordsHistory(t *testing.T) {
+	useDataset(t, threeItems()...)
+	setLabel(0, "x")
+	if err := undo(); err != nil {
+		t.Fatal(err)
+	}
+	if err := redo(); err != nil {
+		t.Fatal(err)
+	}
+	if got := dataset[0].Version; got != 3 {
+		t.Errorf("version = %d, want 3", got)
+	}
+	var got []string
+	for _, record := range auditLog {
+		if record.Field != "label" {
+			continue
+		}
+		got = append(got, fmt.Sprintf("v%d %v", record.Version, record.NewValue))
+	}
+	want := []string{"v1 x", "v2 a", "v3 x"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("audit log = %v, want %v", got, want)
+	}
+
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := undo(); err != nil {
+		t.Fatal(err)
+	}
+	if last := auditLog[len(auditLog)-1]; last.Field != "restored" || last.ItemID != 2 {
+		t.Errorf("last record after undoing a delete = %+v", last)
+	}
+}
+
+func TestUndoImport(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "existing"})
+	for _, input := range []string{"text\nfirst\n", "text\nsecond a\nsecond b\n"} {
+		if _, err := importCSV(strings.NewReader(input)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := [][]int{{1, 2}, {1}}
+	for i, wantIDs := range want {
+		if err := undoImport(); err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(); !reflect.DeepEqual(got, wantIDs) {
+			t.Errorf("after undo %d: IDs = %v, want %v", i+1, got, wantIDs)
+		}
+	}
+	if err := undoImport(); err == nil {
+		t.Error("undoImport with no imports left should fail")
+	}
+	// IDs of the undone imports stay retired
+	if _, err := importCSV(strings.NewReader("text\nagain\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{1, 5}) {
+		t.Errorf("IDs after re-import = %v, want [1 5]", got)
+	}
+}
+
+func TestUndoImportAfterMerge(t *testing.T) {
+	// The counter lags behind the dataset until usedIDs syncs it, so the
+	// import must not rewind it to the stale value afterwards
+	useDataset(t, DataItem{ID: 1, Text: "existing"}, DataItem{ID: 7, Text: "merged"})
+	if _, err := importCSV(strings.NewReader("text\nnew\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := undoImport(); err != nil {
+		t.Fatal(err)
+	}
+	if err := deleteItem(1); err != nil {
+		t.Fatal(err)
+	}
+	if purged := emptyTrash(); purged != 1 {
+		t.Fatalf("emptyTrash = %d, want 1", purged)
+	}
+	if _, err := importCSV(strings.NewReader("text\nlater\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); !reflect.DeepEqual(got, []int{1, 9}) {
+		t.Errorf("IDs = %v, want [1 9]", got)
+	}
+}
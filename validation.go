@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// ValidationRules describes what a well-formed item looks like
+type ValidationRules struct {
+	RequiredFields []string // any of "text", "label", "category", "tags"
+	AllowedLabels  []string // empty allows any label
+	MaxTextLength  int      // in characters; zero means no limit
+}
+
+// ValidationError reports why the item at Index broke the rules
+type ValidationError struct {
+	Index  int
+	Reason string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("item %d: %s", e.Index, e.Reason)
+}
+
+// validationRules are the rules applied by validate and by strict imports
+var validationRules ValidationRules
+
+// strictImport makes the file importers reject files containing items that
+// fail validationRules
+var strictImport bool
+
+// validate checks every item in the dataset against validationRules
+func validate() []ValidationError {
+	datasetMu.RLock()
+	defer datasetMu.RUnlock()
+
+	return validateItems(dataset)
+}
+
+func validateItems(items []DataItem) []ValidationError {
+	allowed := make(map[string]bool)
+	for _, label := range validationRules.AllowedLabels {
+		allowed[label] = true
+	}
+
+	var problems []ValidationError
+	for i, item := range items {
+		for _, field := range validationRules.RequiredFields {
+			missing := false
+			switch field {
+			case "text":
+				missing = item.Text == ""
+			case "label":
+				missing = item.Label == ""
+			case "category":
+				missing = item.Category == ""
+			case "tags":
+				missing = len(item.Tags) == 0
+			}
+			if missing {
+				problems = append(problems, ValidationError{i, field + " is required"})
+			}
+		}
+		if len(allowed) > 0 && item.Label != "" && !allowed[item.Label] {
+			problems = append(problems, ValidationError{i, fmt.Sprintf("label %q is not allowed", item.Label)})
+		}
+		if limit := validationRules.MaxTextLength; limit > 0 && utf8.RuneCountInString(item.Text) > limit {
+			problems = append(problems, ValidationError{i, fmt.Sprintf("text is longer than %d characters", limit)})
+		}
+	}
+	return problems
+}
+
+// importValidationError combines the problems found in imported rows into one error
+func importValidationError(problems []ValidationError) error {
+	errs := make([]error, len(problems))
+	for i, problem := range problems {
+		errs[i] = fmt.Errorf("row %d: %s", problem.Index+1, problem.Reason)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateItems(t *testing.T) {
+	validationRules = ValidationRules{
+		RequiredFields: []string{"text"},
+		AllowedLabels:  []string{"pos", "neg"},
+		MaxTextLength:  5,
+	}
+	defer func() { validationRules = ValidationRules{} }()
+
+	tests := []struct {
+		name string
+		item DataItem
+		want []ValidationError
+	}{
+		{"valid", DataItem{Text: "ok", Label: "pos"}, nil},
+		{"empty required text", DataItem{Label: "pos"}, []ValidationError{{0, "text is required"}}},
+		{"label not allowed", DataItem{Text: "ok", Label: "maybe"}, []ValidationError{{0, `label "maybe" is not allowed`}}},
+		{"text too long", DataItem{Text: "ünïcödé"}, []ValidationError{{0, "text is longer than 5 characters"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validateItems([]DataItem{tt.item}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("validateItems = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrictImport(t *testing.T) {
+	tests := []struct {
+		name string
+		good string
+		bad  string
+		run  func(string) error
+	}{
+		{
+			name: "csv",
+			good: "text,label\nfine,pos\n",
+			bad:  "text,label\nfine,pos\n,pos\n",
+			run: func(input string) error {
+				_, err := importCSV(strings.NewReader(input))
+				return err
+			},
+		},
+		{
+			name: "tsv",
+			good: "text\tlabel\nfine\tpos\n",
+			bad:  "text\tlabel\nfine\tother\n",
+			run: func(input string) error {
+				_, err := importTSV(strings.NewReader(input))
+				return err
+			},
+		},
+		{
+			name: "jsonl",
+			good: `{"text": "fine", "label": "pos"}`,
+			bad:  "{\"text\": \"fine\", \"label\": \"pos\"}\n{\"text\": \"\", \"label\": \"pos\"}",
+			run: func(input string) error {
+				return importJSONL(strings.NewReader(input))
+			},
+		},
+		{
+			name: "json",
+			good: `{"metadata": {}, "data": [{"Text": "fine", "Label": "pos"}]}`,
+			bad:  `{"metadata": {}, "data": [{"Text": "far too long", "Label": "pos"}]}`,
+			run: func(input string) error {
+				return importJSON(strings.NewReader(input), false)
+			},
+		},
+	}
+	validationRules = ValidationRules{
+		RequiredFields: []string{"text"},
+		AllowedLabels:  []string{"pos"},
+		MaxTextLength:  10,
+	}
+	strictImport = true
+	defer func() {
+		validationRules = ValidationRules{}
+		strictImport = false
+	}()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDataset(t)
+			if err := tt.run(tt.bad); err == nil {
+				t.Error("invalid file imported without error")
+			}
+			if len(dataset) != 0 {
+				t.Errorf("invalid file added %d items", len(dataset))
+			}
+			if err := tt.run(tt.good); err != nil {
+				t.Errorf("valid file rejected: %v", err)
+			}
+			if len(dataset) != 1 {
+				t.Errorf("valid file added %d items, want 1", len(dataset))
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"crypto/sha256"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long the watched file must stay quiet before a reload,
+// so an editor saving in several writes only triggers one
+var watchDebounce = 200 * time.Millisecond
+
+// savedSessionSums maps each absolute path saveSession wrote to a checksum of its
+// content, letting watchFile ignore the app's own saves
+var savedSessionSums sync.Map
+
+// watchFile reloads the session at path with loadSession whenever the file
+// changes on disk and then calls onChange with the result, typically to
+// refresh the UI
+// The parent directory is watched so editors that replace the file still
+// trigger a reload. Call stop to end watching
+func watchFile(path string, onChange func(error)) (stop func() error, err error) {
+	path, err = filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	var lastSum [sha256.Size]byte
+	if content, err := os.ReadFile(path); err == nil {
+		lastSum = sha256.Sum256(content)
+	}
+
+	reload := func() {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			// The file is mid-replace or gone; a later event will follow
+			return
+		}
+		sum := sha256.Sum256(content)
+		if saved, ok := savedSessionSums.Load(path); sum == lastSum || (ok && saved == sum) {
+			lastSum = sum
+			return
+		}
+		lastSum = sum
+		onChange(loadSession(path))
+	}
+
+	go func() {
+		var timer *time.Timer
+		var mu sync.Mutex
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					mu.Lock()
+					if timer != nil {
+						timer.Stop()
+					}
+					mu.Unlock()
+					return
+				}
+				if filepath.Clean(event.Name) != path ||
+					!event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) && !event.Has(fsnotify.Rename) {
+					continue
+				}
+				mu.Lock()
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(watchDebounce, func() {
+					mu.Lock()
+					defer mu.Unlock()
+					reload()
+				})
+				mu.Unlock()
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return watcher.Close, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatchFile(t *testing.T) {
+	useDataset(t, DataItem{ID: 1, Text: "one", Label: "a"})
+	watchDebounce = 20 * time.Millisecond
+	defer func() { watchDebounce = 200 * time.Millisecond }()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "session.json")
+	if err := saveSession(path); err != nil {
+		t.Fatal(err)
+	}
+	// An edited copy, written as another program would
+	setLabel(0, "external")
+	external := filepath.Join(dir, "external.json")
+	if err := saveSession(external); err != nil {
+		t.Fatal(err)
+	}
+	content, err := os.ReadFile(external)
+	if err != nil {
+		t.Fatal(err)
+	}
+	setLabel(0, "b")
+
+	reloads := make(chan error, 10)
+	stop, err := watchFile(path, func(err error) { reloads <- err })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	// The app's own save is not a change to reload
+	if err := saveSession(path); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		t.Fatalf("own save triggered a reload: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("external write did not trigger a reload")
+	}
+	if got := labelsOf(); !reflect.DeepEqual(got, []string{"external"}) {
+		t.Errorf("labels after reload = %v, want [external]", got)
+	}
+
+	// Other files in the directory are ignored
+	if err := os.WriteFile(filepath.Join(dir, "other.json"), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-reloads:
+		t.Errorf("unrelated file triggered a reload: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+}